@@ -0,0 +1,204 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+)
+
+// fleetKeywordLimit caps the keywords listed in the text output; the JSON
+// summary has all of them.
+const fleetKeywordLimit = 20
+
+type aggregatedApp struct {
+	FleetApp
+	categories map[string]int
+	keywords   map[FleetKeyword]bool
+	methods    map[string]bool
+}
+
+// Aggregator combines the reports of several apps into one FleetSummary.
+// An APK added twice, by SHA-256, is counted once. Add is safe for
+// concurrent use, so apps scanned in parallel can share an Aggregator.
+type Aggregator struct {
+	mu         sync.Mutex
+	apps       []*aggregatedApp
+	seen       map[string]bool
+	categories []CategorySummary
+}
+
+func NewAggregator() *Aggregator {
+	return &Aggregator{seen: make(map[string]bool)}
+}
+
+type FleetApp struct {
+	APK       string `json:"apk"`
+	APKSHA256 string `json:"apk_sha256,omitempty"`
+	AppInfo
+	MatchedMethods int `json:"matched_methods"`
+}
+
+type FleetCategory struct {
+	ID       string `json:"id"`
+	Category string `json:"category"`
+	// Apps is the number of apps with at least one method in the category.
+	Apps    int `json:"apps"`
+	Methods int `json:"methods"`
+}
+
+type FleetKeyword struct {
+	Keyword  string `json:"keyword"`
+	Category string `json:"category"`
+	Apps     int    `json:"apps"`
+}
+
+// FleetMethod is a matched method found in several apps, typically from a
+// shared library.
+type FleetMethod struct {
+	Method string `json:"method"`
+	Apps   int    `json:"apps"`
+}
+
+type FleetSummary struct {
+	Tool          string          `json:"tool"`
+	Version       string          `json:"version"`
+	Apps          []FleetApp      `json:"apps"`
+	Categories    []FleetCategory `json:"categories"`
+	Keywords      []FleetKeyword  `json:"keywords"`
+	SharedMethods []FleetMethod   `json:"shared_methods,omitempty"`
+}
+
+// Add records the findings of one app.
+func (a *Aggregator) Add(report *Report) {
+	app := &aggregatedApp{
+		FleetApp:   FleetApp{APK: report.APK, APKSHA256: report.APKSHA256, AppInfo: report.AppInfo},
+		categories: make(map[string]int),
+		keywords:   make(map[FleetKeyword]bool),
+		methods:    make(map[string]bool),
+	}
+	for _, summary := range report.Summary {
+		app.categories[summary.ID] = summary.Methods
+	}
+	for _, finding := range report.Findings {
+		if len(finding.Keywords) == 0 && len(finding.Detections) == 0 {
+			continue
+		}
+		app.MatchedMethods++
+		app.methods[finding.Method] = true
+		for category, keywords := range finding.Categories {
+			for _, keyword := range keywords {
+				app.keywords[FleetKeyword{Keyword: keyword, Category: category}] = true
+			}
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	key := cmp.Or(report.APKSHA256, report.APK)
+	if a.seen[key] {
+		return
+	}
+	a.seen[key] = true
+	a.apps = append(a.apps, app)
+	for _, summary := range report.Summary {
+		if !slices.ContainsFunc(a.categories, func(c CategorySummary) bool { return c.ID == summary.ID }) {
+			a.categories = append(a.categories, summary)
+		}
+	}
+}
+
+// Summary returns the fleet-level view of the apps added so far: how many
+// apps hit each category, keyword and matched method, the most common first.
+func (a *Aggregator) Summary() *FleetSummary {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	summary := &FleetSummary{Tool: "boolseeker", Version: version, Apps: []FleetApp{}, Categories: []FleetCategory{}, Keywords: []FleetKeyword{}}
+	keywordApps := make(map[FleetKeyword]int)
+	methodApps := make(map[string]int)
+	for _, app := range a.apps {
+		summary.Apps = append(summary.Apps, app.FleetApp)
+		for keyword := range app.keywords {
+			keywordApps[keyword]++
+		}
+		for method := range app.methods {
+			methodApps[method]++
+		}
+	}
+	slices.SortStableFunc(summary.Apps, func(x, y FleetApp) int { return cmp.Compare(x.APK, y.APK) })
+
+	for _, category := range a.categories {
+		fleetCategory := FleetCategory{ID: category.ID, Category: category.Category}
+		for _, app := range a.apps {
+			if methods := app.categories[category.ID]; methods > 0 {
+				fleetCategory.Apps++
+				fleetCategory.Methods += methods
+			}
+		}
+		summary.Categories = append(summary.Categories, fleetCategory)
+	}
+
+	for keyword, apps := range keywordApps {
+		keyword.Apps = apps
+		summary.Keywords = append(summary.Keywords, keyword)
+	}
+	slices.SortFunc(summary.Keywords, func(x, y FleetKeyword) int {
+		return cmp.Or(y.Apps-x.Apps, cmp.Compare(x.Keyword, y.Keyword), cmp.Compare(x.Category, y.Category))
+	})
+
+	for method, apps := range methodApps {
+		if apps > 1 {
+			summary.SharedMethods = append(summary.SharedMethods, FleetMethod{Method: method, Apps: apps})
+		}
+	}
+	slices.SortFunc(summary.SharedMethods, func(x, y FleetMethod) int {
+		return cmp.Or(y.Apps-x.Apps, cmp.Compare(x.Method, y.Method))
+	})
+	return summary
+}
+
+func WriteFleetSummary(path string, summary *FleetSummary, pretty bool) error {
+	content, err := marshalJSON(summary, pretty)
+	if err != nil {
+		return err
+	}
+	content = append(content, '\n')
+
+	if path == "" || path == "-" {
+		_, err = os.Stdout.Write(content)
+		return err
+	}
+	return os.WriteFile(path, content, 0644)
+}
+
+func PrintFleetSummary(summary *FleetSummary) {
+	total := len(summary.Apps)
+	fmt.Fprintln(console, cyan("=== %d apps ===", total))
+	fmt.Fprintln(console, yellow("✔ Categories across %d apps:", total))
+	for _, category := range summary.Categories {
+		line := fmt.Sprintf("  %s: %d of %d apps (%d methods)", category.Category, category.Apps, total, category.Methods)
+		if category.Apps == 0 {
+			fmt.Fprintln(status, white("%s", line))
+			continue
+		}
+		fmt.Fprintln(console, cyan("%s", line))
+	}
+	fmt.Fprintln(console)
+
+	if len(summary.Keywords) == 0 {
+		fmt.Fprintln(status, red("X No keywords found in any app."))
+		fmt.Fprintln(status)
+		return
+	}
+	fmt.Fprintln(console, yellow("✔ Most common keywords:"))
+	for i, keyword := range summary.Keywords {
+		if i == fleetKeywordLimit {
+			fmt.Fprintf(console, "  %s\n", white("... and %d more", len(summary.Keywords)-i))
+			break
+		}
+		fmt.Fprintf(console, "  %s %s%s\n", cyan("+ %s", keyword.Keyword), white("(%s) - ", keyword.Category), red("%d of %d apps", keyword.Apps, total))
+	}
+	fmt.Fprintln(console)
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/0xdeny/boolseeker/pkg/scanner"
+)
+
+func PrintAppFileMatches(results *AppFileResults) {
+	if len(results.Keywords) == 0 {
+		fmt.Fprintln(status, red("X No keywords found in the manifest or assets."))
+		fmt.Fprintln(status)
+		return
+	}
+
+	files := make([]string, 0, len(results.Keywords))
+	for file := range results.Keywords {
+		files = append(files, file)
+	}
+	slices.Sort(files)
+
+	fmt.Fprintln(console, yellow("✔ Manifest and asset files containing keywords (%s):", scanner.AppFilesCategory.Name))
+	for _, file := range files {
+		fmt.Fprintf(console, "  %s %s%s\n", cyan("+ %s", file), white("- "), red("Keywords found: %s", strings.Join(results.Keywords[file], ", ")))
+	}
+	fmt.Fprintln(console)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// AppInfo identifies the scanned app, so archived reports describe
+// themselves. Fields that could not be read are empty.
+type AppInfo struct {
+	Package     string `json:"package,omitempty"`
+	VersionName string `json:"version_name,omitempty"`
+	VersionCode string `json:"version_code,omitempty"`
+}
+
+func (a AppInfo) String() string {
+	info := a.Package
+	if a.VersionName != "" {
+		info += " " + a.VersionName
+	}
+	if a.VersionCode != "" {
+		info += fmt.Sprintf(" (%s)", a.VersionCode)
+	}
+	return strings.TrimSpace(info)
+}
+
+type apktoolMeta struct {
+	VersionInfo struct {
+		VersionCode string `yaml:"versionCode"`
+		VersionName string `yaml:"versionName"`
+	} `yaml:"versionInfo"`
+}
+
+type manifestInfo struct {
+	Package     string `xml:"package,attr"`
+	VersionCode string `xml:"http://schemas.android.com/apk/res/android versionCode,attr"`
+	VersionName string `xml:"http://schemas.android.com/apk/res/android versionName,attr"`
+}
+
+// ReadAppInfo reads the package name from the decoded AndroidManifest.xml
+// and the version from it or, as apktool moves it there, from apktool.yml.
+// The manifest stays binary with --smali-only and the dex engine, which
+// leaves the package name empty.
+func ReadAppInfo(decodedDir string) AppInfo {
+	var info AppInfo
+	var manifest manifestInfo
+	if data, err := os.ReadFile(filepath.Join(decodedDir, "AndroidManifest.xml")); err == nil && xml.Unmarshal(data, &manifest) == nil {
+		info = AppInfo{Package: manifest.Package, VersionName: manifest.VersionName, VersionCode: manifest.VersionCode}
+	}
+
+	data, err := os.ReadFile(filepath.Join(decodedDir, "apktool.yml"))
+	if err != nil {
+		return info
+	}
+	// Older apktool releases start the file with a Java class tag.
+	if first, rest, ok := strings.Cut(string(data), "\n"); ok && strings.HasPrefix(first, "!!") {
+		data = []byte(rest)
+	}
+	var meta apktoolMeta
+	if yaml.Unmarshal(data, &meta) == nil {
+		if info.VersionName == "" {
+			info.VersionName = meta.VersionInfo.VersionName
+		}
+		if info.VersionCode == "" {
+			info.VersionCode = meta.VersionInfo.VersionCode
+		}
+	}
+	return info
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"cmp"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+var archiveExtensions = []string{".zip", ".tar", ".tar.gz", ".tgz", ".gz", ".tar.xz", ".txz", ".xz"}
+
+// isArchiveInput reports whether an input is a bundle of build artifacts to
+// extract an APK from rather than an APK itself.
+func isArchiveInput(name string) bool {
+	return trimArchiveExt(name) != name
+}
+
+// trimArchiveExt removes the archive extension of a file name, so
+// bundle.tar.gz becomes bundle and app.apk.gz becomes app.apk.
+func trimArchiveExt(name string) string {
+	for _, ext := range archiveExtensions {
+		if strings.HasSuffix(strings.ToLower(name), ext) {
+			return name[:len(name)-len(ext)]
+		}
+	}
+	return name
+}
+
+// ExtractAPK extracts the .apk files of a .zip, .tar, .tar.gz, .tgz, .tar.xz
+// or .txz archive, or decompresses a gzip- or xz-compressed APK such as
+// app.apk.gz, into a temporary directory and returns the first valid APK in path order. name is
+// the input's file name, which selects the archive format. The caller
+// removes the directory with the returned cleanup function.
+func ExtractAPK(archive, name string) (string, func(), error) {
+	directory, err := os.MkdirTemp("", "boolseeker-archive-")
+	if err != nil {
+		return "", nil, fmt.Errorf("✖ Error extracting %s: %w", name, err)
+	}
+	cleanup := func() { os.RemoveAll(directory) }
+
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		err = extractZipAPKs(archive, directory)
+	case strings.HasSuffix(lower, ".tar"):
+		err = extractTarAPKs(archive, directory, false)
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		err = extractTarAPKs(archive, directory, true)
+	case strings.HasSuffix(lower, ".tar.xz"), strings.HasSuffix(lower, ".txz"):
+		tarPath := filepath.Join(directory, "archive.tar")
+		if err = unxzFile(archive, tarPath); err == nil {
+			err = extractTarAPKs(tarPath, directory, false)
+		}
+	default:
+		apkName := trimArchiveExt(filepath.Base(name))
+		if !strings.HasSuffix(strings.ToLower(apkName), ".apk") {
+			apkName += ".apk"
+		}
+		if strings.HasSuffix(lower, ".xz") {
+			err = unxzFile(archive, filepath.Join(directory, apkName))
+		} else {
+			err = gunzipFile(archive, filepath.Join(directory, apkName))
+		}
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("✖ Error extracting %s: %w", name, err)
+	}
+
+	var apks []string
+	filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() && strings.HasSuffix(strings.ToLower(path), ".apk") {
+			apks = append(apks, path)
+		}
+		return nil
+	})
+	slices.Sort(apks)
+	for _, apk := range apks {
+		if validateAPK(apk) == nil {
+			return apk, cleanup, nil
+		}
+	}
+	cleanup()
+	return "", nil, fmt.Errorf("✖ No valid APK found in %s", name)
+}
+
+// extractedPath returns where an archive entry is written below directory,
+// or false for entries that are not APKs or would escape directory.
+func extractedPath(directory, entry string) (string, bool) {
+	if !strings.HasSuffix(strings.ToLower(entry), ".apk") || !filepath.IsLocal(entry) {
+		return "", false
+	}
+	return filepath.Join(directory, entry), true
+}
+
+func writeExtracted(path string, src io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, src); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func extractZipAPKs(archive, directory string) error {
+	reader, err := zip.OpenReader(archive)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	for _, file := range reader.File {
+		path, ok := extractedPath(directory, file.Name)
+		if !ok || file.FileInfo().IsDir() {
+			continue
+		}
+		src, err := file.Open()
+		if err != nil {
+			return err
+		}
+		err = writeExtracted(path, src)
+		src.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractTarAPKs(archive, directory string, compressed bool) error {
+	file, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var src io.Reader = file
+	if compressed {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		src = gz
+	}
+
+	reader := tar.NewReader(src)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		path, ok := extractedPath(directory, header.Name)
+		if !ok || header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := writeExtracted(path, reader); err != nil {
+			return err
+		}
+	}
+}
+
+func gunzipFile(archive, path string) error {
+	file, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+	return writeExtracted(path, gz)
+}
+
+// unxzFile decompresses an xz file with the xz command line tool, as the
+// standard library has no xz decoder.
+func unxzFile(archive, path string) error {
+	xz, err := exec.LookPath("xz")
+	if err != nil {
+		return fmt.Errorf("xz is not installed or not found in PATH; it is required for .xz inputs")
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command(xz, "--decompress", "--stdout", archive)
+	cmd.Stdout = file
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		file.Close()
+		return fmt.Errorf("xz failed: %s", cmp.Or(strings.TrimSpace(stderr.String()), err.Error()))
+	}
+	return file.Close()
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestAPK(t *testing.T, path string) []byte {
+	t.Helper()
+	var content bytes.Buffer
+	writer := zip.NewWriter(&content)
+	for _, name := range []string{"AndroidManifest.xml", "classes.dex"} {
+		if _, err := writer.Create(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, content.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return content.Bytes()
+}
+
+func xzCompress(t *testing.T, path string) string {
+	t.Helper()
+	if err := exec.Command("xz", "--compress", "--keep", path).Run(); err != nil {
+		t.Fatal(err)
+	}
+	return path + ".xz"
+}
+
+func TestExtractAPKXz(t *testing.T) {
+	if _, err := exec.LookPath("xz"); err != nil {
+		t.Skip("xz not found in PATH")
+	}
+	dir := t.TempDir()
+	apk := writeTestAPK(t, filepath.Join(dir, "app.apk"))
+
+	var tarContent bytes.Buffer
+	writer := tar.NewWriter(&tarContent)
+	if err := writer.WriteHeader(&tar.Header{Name: "build/outputs/app-release.apk", Mode: 0644, Size: int64(len(apk))}); err != nil {
+		t.Fatal(err)
+	}
+	writer.Write(apk)
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "bundle.tar"), tarContent.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		archive string
+		apkName string
+	}{
+		{xzCompress(t, filepath.Join(dir, "app.apk")), "app.apk"},
+		{xzCompress(t, filepath.Join(dir, "bundle.tar")), "app-release.apk"},
+	}
+	for _, test := range tests {
+		t.Run(filepath.Base(test.archive), func(t *testing.T) {
+			if !isArchiveInput(test.archive) {
+				t.Fatalf("isArchiveInput(%q) = false", test.archive)
+			}
+			extracted, cleanup, err := ExtractAPK(test.archive, filepath.Base(test.archive))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer cleanup()
+			if filepath.Base(extracted) != test.apkName {
+				t.Errorf("extracted %s, want %s", filepath.Base(extracted), test.apkName)
+			}
+			content, err := os.ReadFile(extracted)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(content, apk) {
+				t.Error("extracted APK differs from the original")
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// isAABFile reports whether the file is an Android App Bundle: a zip with a
+// BUNDLE-METADATA directory and a base module holding the manifest.
+func isAABFile(aabFile string) (bool, error) {
+	fileInfo, err := os.Stat(aabFile)
+	if err != nil {
+		return false, fmt.Errorf("could not stat file: %w", err)
+	}
+
+	if fileInfo.IsDir() {
+		return false, nil
+	}
+
+	zipReader, err := zip.OpenReader(aabFile)
+	if err != nil {
+		return false, nil
+	}
+	defer zipReader.Close()
+
+	hasMetadata, hasBaseManifest := false, false
+	for _, file := range zipReader.File {
+		if strings.HasPrefix(file.Name, "BUNDLE-METADATA/") {
+			hasMetadata = true
+		}
+		if file.Name == "base/manifest/AndroidManifest.xml" {
+			hasBaseManifest = true
+		}
+	}
+
+	return hasMetadata && hasBaseManifest, nil
+}
+
+func CheckBundletool() error {
+	_, err := exec.LookPath("bundletool")
+	if err != nil {
+		return fmt.Errorf("✖️ bundletool is not installed or not found in PATH")
+	}
+	return nil
+}
+
+// buildUniversalAPK converts an App Bundle into a single universal APK inside
+// workDirectory and returns its path.
+func buildUniversalAPK(ctx context.Context, aabFile, workDirectory string) (string, error) {
+	apksFile := filepath.Join(workDirectory, "bundle.apks")
+	cmd := exec.CommandContext(ctx, "bundletool", "build-apks", "--bundle="+aabFile, "--output="+apksFile, "--mode=universal")
+	slog.Debug("running bundletool", "args", cmd.Args)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", fmt.Errorf("bundletool build-apks failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	zipReader, err := zip.OpenReader(apksFile)
+	if err != nil {
+		return "", fmt.Errorf("could not open %s: %w", apksFile, err)
+	}
+	defer zipReader.Close()
+
+	for _, file := range zipReader.File {
+		if file.Name != "universal.apk" {
+			continue
+		}
+		src, err := file.Open()
+		if err != nil {
+			return "", err
+		}
+		defer src.Close()
+
+		apkFile := filepath.Join(workDirectory, "universal.apk")
+		dst, err := os.Create(apkFile)
+		if err != nil {
+			return "", err
+		}
+		if _, err := io.Copy(dst, src); err != nil {
+			dst.Close()
+			return "", err
+		}
+		return apkFile, dst.Close()
+	}
+	return "", fmt.Errorf("no universal.apk in %s", apksFile)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+func PrintCallers(callers map[string][]string) {
+	methods := make([]string, 0, len(callers))
+	for method := range callers {
+		methods = append(methods, method)
+	}
+	slices.Sort(methods)
+	if len(methods) == 0 {
+		fmt.Fprintln(status, red("X No boolean methods with keywords or detections to find callers of."))
+		fmt.Fprintln(status)
+		return
+	}
+
+	fmt.Fprintln(console, yellow("✔ Callers of boolean methods with keywords or detections:"))
+	for _, method := range methods {
+		if len(callers[method]) == 0 {
+			fmt.Fprintf(console, "  %s- %s\n", cyan("+ Java method: %s ", method), yellow("No callers found (dead code, or only called through reflection or a subclass)"))
+			continue
+		}
+		fmt.Fprintf(console, "  %s- %s\n", cyan("+ Java method: %s ", method), red("Called from: %s", strings.Join(callers[method], ", ")))
+	}
+	fmt.Fprintln(console)
+}
+
+func PrintEarlyInitChecks(checks map[string][]string) {
+	if len(checks) == 0 {
+		fmt.Fprintln(status, red("X No boolean methods invoked from early-init entry points."))
+		fmt.Fprintln(status)
+		return
+	}
+
+	fmt.Fprintln(console, yellow("✔ Early-init checks (boolean methods invoked from Application/ContentProvider startup):"))
+	for method, entries := range checks {
+		fmt.Fprintf(console, "  %s- %s\n", cyan("+ Java method: %s ", method), red("Reached from: %s", strings.Join(entries, ", ")))
+	}
+	fmt.Fprintln(console)
+}
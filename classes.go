@@ -0,0 +1,106 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ClassSummary aggregates the boolean methods of one class that have
+// keywords or detections.
+type ClassSummary struct {
+	Class      string   `json:"class"`
+	Methods    []string `json:"methods"`
+	Keywords   []string `json:"keywords,omitempty"`
+	Detections []string `json:"detections,omitempty"`
+	Score      int      `json:"score"`
+}
+
+// shortMethodName returns a method name without its class, in either the
+// dotted or the descriptor format.
+func shortMethodName(method, class string) string {
+	if _, name, ok := strings.Cut(method, "->"); ok {
+		return name
+	}
+	return strings.TrimPrefix(method, class+".")
+}
+
+// GroupFindingsByClass groups the findings with keywords or detections by
+// class, the classes with the most such methods first, then by total score.
+func GroupFindingsByClass(findings []Finding) []ClassSummary {
+	byClass := make(map[string]*ClassSummary)
+	seen := make(map[string]bool)
+	for _, finding := range findings {
+		if len(finding.Keywords) == 0 && len(finding.Detections) == 0 || seen[finding.Method] {
+			continue
+		}
+		seen[finding.Method] = true
+
+		summary, ok := byClass[finding.Class]
+		if !ok {
+			summary = &ClassSummary{Class: finding.Class}
+			byClass[finding.Class] = summary
+		}
+		summary.Methods = append(summary.Methods, shortMethodName(finding.Method, finding.Class))
+		summary.Score += finding.Score
+		for _, keyword := range finding.Keywords {
+			if !slices.Contains(summary.Keywords, keyword) {
+				summary.Keywords = append(summary.Keywords, keyword)
+			}
+		}
+		for _, detection := range finding.Detections {
+			if !slices.Contains(summary.Detections, detection.Detector) {
+				summary.Detections = append(summary.Detections, detection.Detector)
+			}
+		}
+	}
+
+	classes := make([]ClassSummary, 0, len(byClass))
+	for _, summary := range byClass {
+		slices.Sort(summary.Methods)
+		classes = append(classes, *summary)
+	}
+	slices.SortFunc(classes, func(a, b ClassSummary) int {
+		return cmp.Or(len(b.Methods)-len(a.Methods), b.Score-a.Score, strings.Compare(a.Class, b.Class))
+	})
+	return classes
+}
+
+func PrintClassSummaries(classes []ClassSummary) {
+	if len(classes) == 0 {
+		fmt.Fprintln(status, red("X No keywords or detections found in Java boolean methods."))
+		fmt.Fprintln(status)
+		return
+	}
+
+	fmt.Fprintln(console, yellow("✔ Classes with boolean methods containing keywords or detections:"))
+	for _, class := range classes {
+		fmt.Fprintf(console, "  %s\n", cyan("+ Class: %s (methods: %d, score %d)", class.Class, len(class.Methods), class.Score))
+		if len(class.Keywords) > 0 {
+			fmt.Fprintf(console, "      %s\n", red("Keywords found: %s", strings.Join(class.Keywords, ", ")))
+		}
+		if len(class.Detections) > 0 {
+			fmt.Fprintf(console, "      %s\n", red("Detections: %s", strings.Join(class.Detections, ", ")))
+		}
+		fmt.Fprintf(console, "      %s\n", white("Methods: %s", strings.Join(class.Methods, ", ")))
+	}
+	fmt.Fprintln(console)
+}
+
+// PrintClassMatches lists the classes whose field declarations or
+// class-level annotations contain keywords, see --scan-class-metadata.
+func PrintClassMatches(matches []ClassMatch) {
+	if len(matches) == 0 {
+		fmt.Fprintln(status, red("X No keywords found in field declarations or class annotations."))
+		fmt.Fprintln(status)
+		return
+	}
+
+	fmt.Fprintln(console, yellow("✔ Classes with fields or annotations containing keywords:"))
+	for _, match := range matches {
+		fmt.Fprintf(console, "  %s- %s\n", cyan("+ Class: %s ", match.Class), red("Keywords found: %s", strings.Join(match.Keywords, ", ")))
+		PrintKeywordLocations(match.Matches, match.Keywords)
+	}
+	fmt.Fprintln(console)
+}
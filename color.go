@@ -0,0 +1,21 @@
+package main
+
+import "fmt"
+
+// colorEnabled is set in main from --no-color, NO_COLOR and whether stdout
+// is a terminal; every colored message goes through colorize.
+var colorEnabled = true
+
+func colorize(code, format string, args ...any) string {
+	text := fmt.Sprintf(format, args...)
+	if !colorEnabled {
+		return text
+	}
+	return "\033[" + code + "m" + text + "\033[0m"
+}
+
+func red(format string, args ...any) string    { return colorize("31", format, args...) }
+func green(format string, args ...any) string  { return colorize("32", format, args...) }
+func yellow(format string, args ...any) string { return colorize("33", format, args...) }
+func cyan(format string, args ...any) string   { return colorize("36", format, args...) }
+func white(format string, args ...any) string  { return colorize("37", format, args...) }
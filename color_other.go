@@ -0,0 +1,9 @@
+//go:build !windows
+
+package main
+
+// enableVirtualTerminal reports whether the terminal handles ANSI escape
+// codes, which it always does outside Windows.
+func enableVirtualTerminal() bool {
+	return true
+}
@@ -0,0 +1,26 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// enableVirtualTerminal turns on ANSI escape handling for the console behind
+// stdout and stderr. Consoles older than Windows 10 do not support it and
+// would print the escape codes, so colors are turned off there.
+func enableVirtualTerminal() bool {
+	for _, file := range []*os.File{os.Stdout, os.Stderr} {
+		handle := windows.Handle(file.Fd())
+		var mode uint32
+		if err := windows.GetConsoleMode(handle, &mode); err != nil {
+			continue
+		}
+		if err := windows.SetConsoleMode(handle, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING); err != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const defaultConfigFile = ".boolseeker.yaml"
+
+// flagAliases maps the short and alternative flag names to the long ones, so
+// a config file setting apk does not override -a given on the command line.
+var flagAliases = map[string]string{"a": "apk", "o": "output", "v": "verbose", "h": "help", "summary-line": "emit-summary-stderr"}
+
+func canonicalFlagName(name string) string {
+	if long, ok := flagAliases[name]; ok {
+		return long
+	}
+	return name
+}
+
+// ApplyConfigFile reads a YAML file mapping flag names to values and sets
+// every flag that was not given on the command line, so command-line flags
+// win over the config file, which wins over the built-in defaults. Lists are
+// joined with commas, except for repeatable flags. A missing file is only an
+// error when it was named explicitly with --config.
+func ApplyConfigFile(path string, explicit bool) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !explicit && errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("could not read config file %s: %w", path, err)
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return fmt.Errorf("could not parse config file %s: %w", path, err)
+	}
+	if len(document.Content) == 0 {
+		return nil
+	}
+	if document.Content[0].Kind != yaml.MappingNode {
+		return fmt.Errorf("invalid config file %s: expected a mapping of option names to values", path)
+	}
+
+	given := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		given[canonicalFlagName(f.Name)] = true
+	})
+
+	mapping := document.Content[0]
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		name := canonicalFlagName(strings.TrimPrefix(mapping.Content[i].Value, "-"))
+		node := mapping.Content[i+1]
+		if name == "config" || name == "help" || flag.Lookup(name) == nil {
+			return fmt.Errorf("invalid config file %s: unknown option %q", path, name)
+		}
+		if given[name] {
+			continue
+		}
+
+		var values []string
+		switch node.Kind {
+		case yaml.ScalarNode:
+			values = []string{node.Value}
+		case yaml.SequenceNode:
+			if err := node.Decode(&values); err != nil {
+				return fmt.Errorf("invalid config file %s: option %q must be a value or a list of values", path, name)
+			}
+			// Repeatable flags such as apk take one value per list item.
+			if _, repeatable := flag.Lookup(name).Value.(*stringsFlag); !repeatable {
+				values = []string{strings.Join(values, ",")}
+			}
+		default:
+			return fmt.Errorf("invalid config file %s: option %q must be a value or a list of values", path, name)
+		}
+
+		for _, value := range values {
+			if err := flag.Set(name, value); err != nil {
+				return fmt.Errorf("invalid config file %s: option %q: %v", path, name, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestApplyConfigFileAliasPrecedence(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		config string
+		want   bool
+	}{
+		{"alias on the command line", []string{"--summary-line=false"}, "emit-summary-stderr: true\n", false},
+		{"long name on the command line", []string{"--emit-summary-stderr=false"}, "summary-line: true\n", false},
+		{"alias in the config file", nil, "summary-line: true\n", true},
+		{"long name in the config file", nil, "emit-summary-stderr: true\n", true},
+	}
+
+	commandLine := flag.CommandLine
+	t.Cleanup(func() { flag.CommandLine = commandLine })
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			flag.CommandLine = flag.NewFlagSet("boolseeker", flag.ContinueOnError)
+			summaryLine := flag.Bool("emit-summary-stderr", false, "")
+			flag.BoolVar(summaryLine, "summary-line", false, "")
+			if err := flag.CommandLine.Parse(test.args); err != nil {
+				t.Fatal(err)
+			}
+
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(test.config), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := ApplyConfigFile(path, true); err != nil {
+				t.Fatal(err)
+			}
+			if *summaryLine != test.want {
+				t.Errorf("summary line = %v, want %v", *summaryLine, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/csv"
+	"os"
+	"slices"
+)
+
+var csvHeader = []string{"method", "class", "smali_path", "category", "keyword", "package", "version_name", "version_code"}
+
+// csvRecords returns one row per method and keyword of each category it
+// matched, and per method and target of each structural detection, with the
+// detector ID as the category. Every row ends with the app's package and
+// version, so rows from several reports can be concatenated.
+func csvRecords(report *Report) [][]string {
+	detectorIDs := make(map[string]string)
+	for _, summary := range report.Summary {
+		detectorIDs[summary.Category] = summary.ID
+	}
+
+	app := []string{report.Package, report.VersionName, report.VersionCode}
+	var records [][]string
+	for _, finding := range report.Findings {
+		categories := make([]string, 0, len(finding.Categories))
+		for id := range finding.Categories {
+			categories = append(categories, id)
+		}
+		slices.Sort(categories)
+		for _, id := range categories {
+			for _, keyword := range finding.Categories[id] {
+				records = append(records, slices.Concat([]string{finding.Method, finding.Class, finding.SmaliPath, id, keyword}, app))
+			}
+		}
+
+		for _, detection := range finding.Detections {
+			id := detectorIDs[detection.Detector]
+			if id == "" {
+				id = detection.Detector
+			}
+			for _, target := range detection.Targets {
+				records = append(records, slices.Concat([]string{finding.Method, finding.Class, finding.SmaliPath, id, target}, app))
+			}
+		}
+	}
+	return records
+}
+
+// WriteCSVReport writes the findings of reports as CSV under a single
+// header, to stdout when path is empty or "-".
+func WriteCSVReport(path string, reports []*Report) error {
+	records := [][]string{csvHeader}
+	for _, report := range reports {
+		records = append(records, csvRecords(report)...)
+	}
+	if path == "" || path == "-" {
+		return csv.NewWriter(os.Stdout).WriteAll(records)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := csv.NewWriter(file).WriteAll(records); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+)
+
+// dbSchema creates the tables --db writes to: one apps row per scan, its
+// boolean methods with keywords or detections, and the keyword hits of each
+// method. Scanning an APK again adds a new apps row.
+const dbSchema = `CREATE TABLE IF NOT EXISTS apps (
+  id INTEGER PRIMARY KEY,
+  apk TEXT NOT NULL,
+  apk_sha256 TEXT NOT NULL,
+  package TEXT,
+  version_name TEXT,
+  version_code TEXT,
+  scanned_at TEXT NOT NULL,
+  boolseeker_version TEXT NOT NULL,
+  total_methods INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS methods (
+  id INTEGER PRIMARY KEY,
+  app_id INTEGER NOT NULL REFERENCES apps(id),
+  method TEXT NOT NULL,
+  class TEXT NOT NULL,
+  smali_path TEXT NOT NULL,
+  score INTEGER NOT NULL,
+  detections TEXT
+);
+CREATE TABLE IF NOT EXISTS keyword_hits (
+  id INTEGER PRIMARY KEY,
+  method_id INTEGER NOT NULL REFERENCES methods(id),
+  category TEXT NOT NULL,
+  keyword TEXT NOT NULL,
+  line INTEGER,
+  context TEXT
+);
+CREATE INDEX IF NOT EXISTS apps_sha256 ON apps(apk_sha256);
+CREATE INDEX IF NOT EXISTS methods_app ON methods(app_id);
+CREATE INDEX IF NOT EXISTS keyword_hits_method ON keyword_hits(method_id);
+`
+
+// CheckSqlite resolves the sqlite3 command line tool --db writes with.
+func CheckSqlite() (string, error) {
+	path, err := exec.LookPath("sqlite3")
+	if err != nil {
+		return "", fmt.Errorf("✖️ sqlite3 is not installed or not found in PATH; it is required by --db")
+	}
+	return path, nil
+}
+
+// sqlQuote returns value as an SQL string literal.
+func sqlQuote(value string) string {
+	return "'" + strings.ReplaceAll(strings.ReplaceAll(value, "\x00", ""), "'", "''") + "'"
+}
+
+// dbStatements returns the statements adding one scan to the database, in a
+// single transaction.
+func dbStatements(report *Report, scannedAt time.Time) string {
+	var sql strings.Builder
+	sql.WriteString(dbSchema)
+	sql.WriteString("BEGIN;\nCREATE TEMP TABLE current (app_id INTEGER, method_id INTEGER);\n")
+	fmt.Fprintf(&sql, "INSERT INTO apps (apk, apk_sha256, package, version_name, version_code, scanned_at, boolseeker_version, total_methods) VALUES (%s, %s, %s, %s, %s, %s, %s, %d);\n",
+		sqlQuote(report.APK), sqlQuote(report.APKSHA256), sqlQuote(report.Package), sqlQuote(report.VersionName), sqlQuote(report.VersionCode),
+		sqlQuote(scannedAt.UTC().Format(time.RFC3339)), sqlQuote(report.Version), report.TotalMethods)
+	sql.WriteString("INSERT INTO current (app_id) VALUES (last_insert_rowid());\n")
+
+	for _, finding := range report.Findings {
+		if len(finding.Keywords) == 0 && len(finding.Detections) == 0 {
+			continue
+		}
+		var detections []string
+		for _, detection := range finding.Detections {
+			detections = append(detections, detection.Detector)
+		}
+		fmt.Fprintf(&sql, "INSERT INTO methods (app_id, method, class, smali_path, score, detections) VALUES ((SELECT app_id FROM current), %s, %s, %s, %d, %s);\n",
+			sqlQuote(finding.Method), sqlQuote(finding.Class), sqlQuote(finding.SmaliPath), finding.Score, sqlQuote(strings.Join(detections, ", ")))
+		sql.WriteString("UPDATE current SET method_id = last_insert_rowid();\n")
+
+		for _, match := range finding.Matches {
+			for _, category := range findingCategories(finding, match.Keyword) {
+				fmt.Fprintf(&sql, "INSERT INTO keyword_hits (method_id, category, keyword, line, context) VALUES ((SELECT method_id FROM current), %s, %s, %d, %s);\n",
+					sqlQuote(category), sqlQuote(match.Keyword), match.Line, sqlQuote(match.Context))
+			}
+		}
+	}
+	sql.WriteString("DROP TABLE current;\nCOMMIT;\n")
+	return sql.String()
+}
+
+// findingCategories returns the IDs of the categories a keyword of finding
+// was counted in, in a stable order.
+func findingCategories(finding Finding, keyword string) []string {
+	var categories []string
+	for category, keywords := range finding.Categories {
+		if slices.Contains(keywords, keyword) {
+			categories = append(categories, category)
+		}
+	}
+	if len(categories) == 0 {
+		return []string{""}
+	}
+	slices.Sort(categories)
+	return categories
+}
+
+// WriteDatabase adds a scan to the SQLite database at path, creating it and
+// its tables when needed, with the sqlite3 tool at sqlitePath.
+func WriteDatabase(sqlitePath, path string, report *Report, scannedAt time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, sqlitePath, "-bail", path)
+	cmd.Stdin = strings.NewReader(dbStatements(report, scannedAt))
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("✖️ Could not write findings to %s: %v %s", path, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DecodeCache keeps decoded APKs in a directory, one entry per APK SHA-256
+// and decoding setup, so scanning the same APK again skips decoding.
+type DecodeCache struct {
+	Directory string
+	// setup identifies the engine and apktool arguments, as a decode with
+	// other arguments, e.g. --no-res, gives a different tree.
+	setup string
+}
+
+func NewDecodeCache(directory, engine string, apktoolArgs []string) (*DecodeCache, error) {
+	if err := os.MkdirAll(directory, 0o755); err != nil {
+		return nil, fmt.Errorf("could not create cache directory %s: %w", directory, err)
+	}
+	setup := engine
+	if engine == "apktool" && len(apktoolArgs) > 0 {
+		setup += "\x00" + strings.Join(apktoolArgs, "\x00")
+	}
+	return &DecodeCache{Directory: directory, setup: setup}, nil
+}
+
+// Entry returns the cache entry of an APK decoded together with splits:
+// <sha256>-<engine>, with a hash of the apktool arguments and the splits
+// appended when there are any.
+func (c *DecodeCache) Entry(apkFile string, splits []string) (string, error) {
+	apkHash, err := hashFile(apkFile)
+	if err != nil {
+		return "", fmt.Errorf("could not hash %s: %w", apkFile, err)
+	}
+	engine, _, _ := strings.Cut(c.setup, "\x00")
+	name := apkHash + "-" + engine
+
+	variant := c.setup
+	for _, split := range splits {
+		splitHash, err := hashFile(split)
+		if err != nil {
+			return "", fmt.Errorf("could not hash %s: %w", split, err)
+		}
+		variant += "\x00" + splitHash
+	}
+	if variant != engine {
+		sum := sha256.Sum256([]byte(variant))
+		name += "-" + hex.EncodeToString(sum[:6])
+	}
+	return filepath.Join(c.Directory, name), nil
+}
+
+// hashFile returns the hex SHA-256 of the file at path, the key of decode
+// cache entries and the APK hash of reports.
+func hashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Has reports whether entry holds a decoded APK.
+func (c *DecodeCache) Has(entry string) bool {
+	info, err := os.Stat(entry)
+	return err == nil && info.IsDir()
+}
+
+// Staging returns a new directory inside the cache to decode into, so that
+// only complete decodes are moved into place by Store.
+func (c *DecodeCache) Staging() (string, error) {
+	return os.MkdirTemp(c.Directory, ".decoding-")
+}
+
+// Store moves a decoded directory into entry. When another run stored the
+// same entry first, that one is kept.
+func (c *DecodeCache) Store(decodedDirectory, entry string) error {
+	if err := os.Rename(decodedDirectory, entry); err != nil && !c.Has(entry) {
+		return fmt.Errorf("could not store %s in the cache: %w", decodedDirectory, err)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/0xdeny/boolseeker/pkg/scanner"
+)
+
+// MethodDiff is a method whose matches changed between two scans, with the
+// keywords or detection targets it gained and lost.
+type MethodDiff struct {
+	Method  string   `json:"method"`
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+type CategoryDiff struct {
+	ID        string       `json:"id"`
+	Category  string       `json:"category"`
+	Added     []MethodDiff `json:"added,omitempty"`
+	Removed   []MethodDiff `json:"removed,omitempty"`
+	Changed   []MethodDiff `json:"changed,omitempty"`
+	Unchanged int          `json:"unchanged"`
+}
+
+func (d CategoryDiff) HasChanges() bool {
+	return len(d.Added) > 0 || len(d.Removed) > 0 || len(d.Changed) > 0
+}
+
+type ReportDiff struct {
+	Baseline   string         `json:"baseline"`
+	Categories []CategoryDiff `json:"categories"`
+}
+
+// categoryMatches maps each category and structural detector ID of a report
+// to the methods it matched and their keywords or detection targets.
+func categoryMatches(report *Report) map[string]map[string][]string {
+	detectorIDs := make(map[string]string)
+	for _, summary := range report.Summary {
+		detectorIDs[summary.Category] = summary.ID
+	}
+
+	matches := make(map[string]map[string][]string)
+	add := func(id, method string, values ...string) {
+		if matches[id] == nil {
+			matches[id] = make(map[string][]string)
+		}
+		for _, value := range values {
+			if !slices.Contains(matches[id][method], value) {
+				matches[id][method] = append(matches[id][method], value)
+			}
+		}
+	}
+
+	for _, finding := range report.Findings {
+		for id, keywords := range finding.Categories {
+			add(id, finding.Method, keywords...)
+		}
+		for _, detection := range finding.Detections {
+			id := detectorIDs[detection.Detector]
+			if id == "" {
+				id = detection.Detector
+			}
+			if len(detection.Targets) == 0 {
+				add(id, finding.Method, detection.Detector)
+			} else {
+				add(id, finding.Method, detection.Targets...)
+			}
+		}
+	}
+	return matches
+}
+
+// difference returns the values of a that are not in b, sorted.
+func difference(a, b []string) []string {
+	var values []string
+	for _, value := range a {
+		if !slices.Contains(b, value) {
+			values = append(values, value)
+		}
+	}
+	slices.Sort(values)
+	return values
+}
+
+// DiffReports compares the boolean method findings of a baseline scan with
+// those of target, per category and structural detector. Methods are matched
+// by name, so renames by an obfuscator show up as a removal and an addition.
+func DiffReports(baseline, target *Report) *ReportDiff {
+	baselineMatches := categoryMatches(baseline)
+	targetMatches := categoryMatches(target)
+
+	var summaries []CategorySummary
+	seen := make(map[string]bool)
+	for _, summary := range slices.Concat(target.Summary, baseline.Summary) {
+		if summary.ID == scanner.AppFilesCategory.ID || summary.ID == scanner.ResourcesCategory.ID || seen[summary.ID] {
+			continue
+		}
+		seen[summary.ID] = true
+		summaries = append(summaries, summary)
+	}
+
+	diff := &ReportDiff{Baseline: baseline.APK}
+	for _, summary := range summaries {
+		categoryDiff := CategoryDiff{ID: summary.ID, Category: summary.Category}
+		before, after := baselineMatches[summary.ID], targetMatches[summary.ID]
+
+		for method, values := range after {
+			previous, ok := before[method]
+			if !ok {
+				categoryDiff.Added = append(categoryDiff.Added, MethodDiff{Method: method, Added: difference(values, nil)})
+				continue
+			}
+			added, removed := difference(values, previous), difference(previous, values)
+			if len(added) == 0 && len(removed) == 0 {
+				categoryDiff.Unchanged++
+				continue
+			}
+			categoryDiff.Changed = append(categoryDiff.Changed, MethodDiff{method, added, removed})
+		}
+		for method, values := range before {
+			if _, ok := after[method]; !ok {
+				categoryDiff.Removed = append(categoryDiff.Removed, MethodDiff{Method: method, Removed: difference(values, nil)})
+			}
+		}
+
+		byMethod := func(a, b MethodDiff) int { return strings.Compare(a.Method, b.Method) }
+		slices.SortFunc(categoryDiff.Added, byMethod)
+		slices.SortFunc(categoryDiff.Removed, byMethod)
+		slices.SortFunc(categoryDiff.Changed, byMethod)
+		diff.Categories = append(diff.Categories, categoryDiff)
+	}
+	return diff
+}
+
+func PrintDiff(diff *ReportDiff) {
+	changed := false
+	for _, category := range diff.Categories {
+		if !category.HasChanges() {
+			continue
+		}
+		if !changed {
+			fmt.Fprintln(console, yellow("✔ Changes since %s:", diff.Baseline))
+			changed = true
+		}
+		fmt.Fprintln(console, yellow("  %s: %d added, %d removed, %d changed, %d unchanged", category.Category, len(category.Added), len(category.Removed), len(category.Changed), category.Unchanged))
+		for _, method := range category.Added {
+			fmt.Fprintf(console, "    %s %s%s\n", green("+ %s", method.Method), white("- "), red("%s", strings.Join(method.Added, ", ")))
+		}
+		for _, method := range category.Removed {
+			fmt.Fprintf(console, "    %s %s%s\n", red("- %s", method.Method), white("- "), white("%s", strings.Join(method.Removed, ", ")))
+		}
+		for _, method := range category.Changed {
+			var parts []string
+			for _, value := range method.Added {
+				parts = append(parts, "+"+value)
+			}
+			for _, value := range method.Removed {
+				parts = append(parts, "-"+value)
+			}
+			fmt.Fprintf(console, "    %s %s%s\n", cyan("~ %s", method.Method), white("- "), white("%s", strings.Join(parts, ", ")))
+		}
+	}
+	if !changed {
+		fmt.Fprintln(console, green("✔ No changes in boolean method findings since %s", diff.Baseline))
+	}
+	fmt.Fprintln(console)
+}
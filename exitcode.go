@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/0xdeny/boolseeker/pkg/scanner"
+)
+
+// Exit codes used with --fail-on. Exit code 1 stays reserved for
+// operational errors such as a missing apktool or an unreadable APK.
+const (
+	exitJavaFindings   = 2
+	exitNativeFindings = 3
+)
+
+// ParseFailOn returns the category IDs that should fail the run. "any"
+// selects every category.
+func ParseFailOn(value string) (map[string]bool, error) {
+	failOn := make(map[string]bool)
+	for _, id := range scanner.CategoryIDs(value, keywordCategories) {
+		if id == "any" {
+			for _, category := range keywordCategories {
+				failOn[category.ID] = true
+			}
+			failOn["custom"] = true
+			continue
+		}
+		if !slices.ContainsFunc(keywordCategories, func(c KeywordCategory) bool { return c.ID == id }) && id != "custom" {
+			return nil, fmt.Errorf("unknown --fail-on category %q", id)
+		}
+		failOn[id] = true
+	}
+	return failOn, nil
+}
+
+// FindingsExitCode returns exitJavaFindings when a boolean method matched a
+// keyword of a failing category, otherwise exitNativeFindings when a .so file
+// did, and 0 when neither did.
+func FindingsExitCode(report *Report, nativeCategories []KeywordCategory, failOn map[string]bool) int {
+	for _, finding := range report.Findings {
+		for id := range finding.Categories {
+			if failOn[id] {
+				return exitJavaFindings
+			}
+		}
+	}
+
+	if report.Native == nil {
+		return 0
+	}
+	for _, category := range nativeCategories {
+		if failOn[category.ID] && len(scanner.FilterCategoryKeywords(category.Keywords, report.Native.Keywords)) > 0 {
+			return exitNativeFindings
+		}
+	}
+	return 0
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+type frameworkMarker struct {
+	Framework string
+	Pattern   string
+}
+
+var frameworkMarkers = []frameworkMarker{
+	{"Flutter", "lib/*/libflutter.so"},
+	{"Flutter", "lib/*/libapp.so"},
+	{"Flutter", "assets/flutter_assets"},
+	{"React Native", "assets/index.android.bundle"},
+	{"React Native", "lib/*/libreactnativejni.so"},
+	{"React Native", "lib/*/libhermes.so"},
+	{"Xamarin", "assemblies"},
+	{"Xamarin", "lib/*/libmonodroid.so"},
+	{"Xamarin", "lib/*/libxamarin-app.so"},
+	{"Unity", "lib/*/libunity.so"},
+	{"Unity", "lib/*/libil2cpp.so"},
+	{"Cordova", "assets/www/cordova.js"},
+}
+
+func detectFramework(decodedDir string) string {
+	for _, marker := range frameworkMarkers {
+		matches, err := filepath.Glob(filepath.Join(decodedDir, filepath.FromSlash(marker.Pattern)))
+		if err != nil {
+			continue
+		}
+		for _, match := range matches {
+			if _, err := os.Stat(match); err == nil {
+				return marker.Framework
+			}
+		}
+	}
+	return ""
+}
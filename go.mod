@@ -3,10 +3,14 @@ module github.com/0xdeny/boolseeker
 go 1.22.0
 
 require (
-	github.com/briandowns/spinner v1.23.1 // indirect
-	github.com/fatih/color v1.7.0 // indirect
+	github.com/briandowns/spinner v1.23.1
+	github.com/fatih/color v1.7.0
+	github.com/mattn/go-isatty v0.0.8
+	golang.org/x/sys v0.0.0-20220412211240-33da011f77ad
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
 	github.com/mattn/go-colorable v0.1.2 // indirect
-	github.com/mattn/go-isatty v0.0.8 // indirect
-	golang.org/x/sys v0.0.0-20220412211240-33da011f77ad // indirect
 	golang.org/x/term v0.1.0 // indirect
 )
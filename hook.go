@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// RunFindingHook runs command with the JSON report on its stdin, killing it
+// after timeout or when ctx is done.
+func RunFindingHook(ctx context.Context, command string, timeout time.Duration, report *Report) error {
+	input, err := marshalJSON(report, false)
+	if err != nil {
+		return err
+	}
+
+	methodsWithKeywords := 0
+	for _, finding := range report.Findings {
+		if len(finding.Keywords) > 0 {
+			methodsWithKeywords++
+		}
+	}
+	nativeFiles := 0
+	if report.Native != nil {
+		nativeFiles = len(report.Native.Keywords)
+	}
+
+	hookCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(hookCtx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(hookCtx, "sh", "-c", command)
+	}
+	cmd.Stdin = bytes.NewReader(input)
+	// Reports may be written to stdout; keep the hook's output out of them.
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(),
+		"BOOLSEEKER_APK="+report.APK,
+		"BOOLSEEKER_METHODS_WITH_KEYWORDS="+strconv.Itoa(methodsWithKeywords),
+		"BOOLSEEKER_NATIVE_FILES="+strconv.Itoa(nativeFiles),
+	)
+
+	err = cmd.Run()
+	if ctx.Err() != nil {
+		return fmt.Errorf("on-finding hook stopped: %w", ctx.Err())
+	}
+	if hookCtx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("on-finding hook timed out after %s", timeout)
+	}
+	if err != nil {
+		return fmt.Errorf("on-finding hook failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"html/template"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/0xdeny/boolseeker/pkg/scanner"
+)
+
+type htmlRow struct {
+	Method    string
+	SmaliPath string
+	Score     int
+	Matched   string
+	Lines     []KeywordMatch
+}
+
+type htmlSection struct {
+	CategorySummary
+	Rows []htmlRow
+}
+
+type htmlFileRow struct {
+	File     string
+	Keywords string
+}
+
+type htmlPage struct {
+	Report    *Report
+	Matched   int
+	Sections  []htmlSection
+	Native    []htmlFileRow
+	AppFiles  []htmlFileRow
+	Resources []htmlFileRow
+}
+
+// newHTMLPage groups the findings of a report by category and structural
+// detector, the highest scoring first.
+func newHTMLPage(report *Report) htmlPage {
+	page := htmlPage{Report: report}
+	for _, finding := range report.Findings {
+		if len(finding.Keywords) > 0 || len(finding.Detections) > 0 {
+			page.Matched++
+		}
+	}
+
+	for _, summary := range report.Summary {
+		if summary.ID == scanner.AppFilesCategory.ID || summary.ID == scanner.ResourcesCategory.ID {
+			continue
+		}
+		section := htmlSection{CategorySummary: summary}
+		for _, finding := range report.Findings {
+			var matched []string
+			if keywords, ok := finding.Categories[summary.ID]; ok {
+				matched = keywords
+			}
+			for _, detection := range finding.Detections {
+				if detection.Detector == summary.Category {
+					matched = append(matched, detection.Targets...)
+					if len(detection.Targets) == 0 {
+						matched = append(matched, detection.Detector)
+					}
+				}
+			}
+			if len(matched) == 0 {
+				continue
+			}
+
+			var lines []KeywordMatch
+			for _, match := range finding.Matches {
+				if match.Line > 0 && slices.Contains(matched, match.Keyword) {
+					lines = append(lines, match)
+				}
+			}
+			section.Rows = append(section.Rows, htmlRow{finding.Method, finding.SmaliPath, finding.Score, strings.Join(matched, ", "), lines})
+		}
+		slices.SortStableFunc(section.Rows, func(a, b htmlRow) int { return b.Score - a.Score })
+		page.Sections = append(page.Sections, section)
+	}
+
+	if report.Native != nil {
+		page.Native = htmlFileRows(report.Native.Keywords)
+	}
+	if report.AppFiles != nil {
+		page.AppFiles = htmlFileRows(report.AppFiles.Keywords)
+	}
+	for _, match := range report.Resources {
+		page.Resources = append(page.Resources, htmlFileRow{match.Resource, strings.Join(match.Keywords, ", ")})
+	}
+	return page
+}
+
+func htmlFileRows(keywordsByFile map[string][]string) []htmlFileRow {
+	var rows []htmlFileRow
+	for file, keywords := range keywordsByFile {
+		rows = append(rows, htmlFileRow{file, strings.Join(keywords, ", ")})
+	}
+	slices.SortFunc(rows, func(a, b htmlFileRow) int { return strings.Compare(a.File, b.File) })
+	return rows
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>boolseeker report: {{.Report.APK}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+h1 { margin-bottom: 0.2em; }
+.meta { color: #666; margin-bottom: 1.5em; }
+.counts span { display: inline-block; margin-right: 2em; font-size: 1.2em; }
+table { border-collapse: collapse; width: 100%; margin: 0.5em 0 1em; }
+th, td { border: 1px solid #ddd; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { background: #f4f4f4; }
+code { font-size: 0.9em; }
+details { margin: 0.5em 0; border: 1px solid #ddd; border-radius: 4px; padding: 0.5em 1em; }
+summary { cursor: pointer; font-weight: bold; }
+.high { color: #b00020; } .medium { color: #b36b00; } .low { color: #666; }
+.empty { color: #999; }
+.lines { color: #555; font-size: 0.85em; margin: 0.3em 0 0; padding-left: 1.2em; }
+#search { width: 100%; padding: 6px; font-size: 1em; margin-bottom: 1em; box-sizing: border-box; }
+</style>
+</head>
+<body>
+<h1>boolseeker report</h1>
+<div class="meta">
+  <div>APK: <code>{{.Report.APK}}</code></div>
+  {{if .Report.AppInfo.String}}<div>App: <code>{{.Report.AppInfo}}</code></div>{{end}}
+  {{if .Report.APKSHA256}}<div>SHA-256: <code>{{.Report.APKSHA256}}</code></div>{{end}}
+  {{if .Report.Framework}}<div>Framework: {{.Report.Framework}}</div>{{end}}
+  {{if .Report.Protection}}<div>Protection SDKs: {{range $i, $sdk := .Report.Protection}}{{if $i}}, {{end}}{{$sdk.Name}}{{end}}</div>{{end}}
+  <div>boolseeker {{.Report.Version}}</div>
+</div>
+<div class="counts">
+  <span>{{.Report.TotalMethods}} boolean methods</span>
+  <span>{{.Matched}} with keywords or detections</span>
+</div>
+
+<h2>Summary</h2>
+<table>
+  <tr><th>Category</th><th>Methods</th><th>Keywords</th><th>Severity</th></tr>
+  {{range .Report.Summary}}<tr><td>{{.Category}}</td><td>{{.Methods}}</td><td>{{.Keywords}}</td><td class="{{.Severity}}">{{.Severity}}</td></tr>
+  {{end}}
+</table>
+
+<h2>Findings</h2>
+<input id="search" type="search" placeholder="Filter by method, smali path or keyword">
+{{range .Sections}}
+<details class="section"{{if .Rows}} open{{end}}>
+  <summary><span class="{{.Severity}}">{{.Category}}</span> ({{len .Rows}})</summary>
+  {{if .Rows}}
+  <table>
+    <tr><th>Method</th><th>Score</th><th>Smali path</th><th>Matched</th></tr>
+    {{range .Rows}}<tr class="row">
+      <td><code>{{.Method}}</code></td>
+      <td>{{.Score}}</td>
+      <td><code>{{.SmaliPath}}</code></td>
+      <td>{{.Matched}}{{if .Lines}}<ul class="lines">{{range .Lines}}<li><code>{{.File}}:{{.Line}}: {{.Context}}</code></li>{{end}}</ul>{{end}}</td>
+    </tr>
+    {{end}}
+  </table>
+  {{else}}<p class="empty">No findings.</p>{{end}}
+</details>
+{{end}}
+
+{{if .Native}}
+<details class="section" open>
+  <summary>.so files ({{len .Native}})</summary>
+  <table>
+    <tr><th>File</th><th>Keywords</th></tr>
+    {{range .Native}}<tr class="row"><td><code>{{.File}}</code></td><td>{{.Keywords}}</td></tr>
+    {{end}}
+  </table>
+</details>
+{{end}}
+
+{{if .AppFiles}}
+<details class="section" open>
+  <summary>Manifest and assets ({{len .AppFiles}})</summary>
+  <table>
+    <tr><th>File</th><th>Keywords</th></tr>
+    {{range .AppFiles}}<tr class="row"><td><code>{{.File}}</code></td><td>{{.Keywords}}</td></tr>
+    {{end}}
+  </table>
+</details>
+{{end}}
+
+{{if .Resources}}
+<details class="section" open>
+  <summary>String resources ({{len .Resources}})</summary>
+  <table>
+    <tr><th>Resource</th><th>Keywords</th></tr>
+    {{range .Resources}}<tr class="row"><td><code>{{.File}}</code></td><td>{{.Keywords}}</td></tr>
+    {{end}}
+  </table>
+</details>
+{{end}}
+
+<script>
+document.getElementById("search").addEventListener("input", function () {
+  var query = this.value.toLowerCase();
+  document.querySelectorAll("tr.row").forEach(function (row) {
+    row.style.display = row.textContent.toLowerCase().indexOf(query) === -1 ? "none" : "";
+  });
+  if (query) {
+    document.querySelectorAll("details.section").forEach(function (section) { section.open = true; });
+  }
+});
+</script>
+</body>
+</html>
+`))
+
+// WriteHTMLReport writes the report as a single self-contained HTML page,
+// to stdout when path is empty or "-".
+func WriteHTMLReport(path string, report *Report) error {
+	var content bytes.Buffer
+	if err := htmlReportTemplate.Execute(&content, newHTMLPage(report)); err != nil {
+		return err
+	}
+
+	if path == "" || path == "-" {
+		_, err := os.Stdout.Write(content.Bytes())
+		return err
+	}
+	return os.WriteFile(path, content.Bytes(), 0644)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// LoadIgnoreFile reads method names or glob patterns, one per line, of
+// known-benign methods. Blank lines and lines starting with # are skipped.
+// In patterns, * matches any run of characters and ? a single one.
+func LoadIgnoreFile(path string) ([]*regexp.Regexp, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read ignore file %s: %w", path, err)
+	}
+	defer file.Close()
+
+	var patterns []*regexp.Regexp
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		expression := regexp.QuoteMeta(line)
+		expression = strings.ReplaceAll(expression, `\*`, ".*")
+		expression = strings.ReplaceAll(expression, `\?`, ".")
+		patterns = append(patterns, regexp.MustCompile("^"+expression+"$"))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read ignore file %s: %w", path, err)
+	}
+	return patterns, nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+type cachedSmaliFile struct {
+	Hash string `json:"hash"`
+	SmaliResults
+}
+
+type SmaliCache struct {
+	CacheKey string                     `json:"cache_key"`
+	Config   ScanConfig                 `json:"config"`
+	Files    map[string]cachedSmaliFile `json:"files"`
+
+	mu       sync.Mutex
+	previous map[string]cachedSmaliFile
+	Reused   int `json:"-"`
+	Scanned  int `json:"-"`
+}
+
+func LoadSmaliCache(path string, config ScanConfig) (*SmaliCache, error) {
+	cache := &SmaliCache{
+		CacheKey: config.CacheKey(),
+		Config:   config,
+		Files:    make(map[string]cachedSmaliFile),
+		previous: make(map[string]cachedSmaliFile),
+	}
+
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cache, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("could not read snapshot %s: %w", path, err)
+	}
+
+	var snapshot SmaliCache
+	if err := json.Unmarshal(content, &snapshot); err != nil {
+		return nil, fmt.Errorf("could not parse snapshot %s: %w", path, err)
+	}
+
+	// Results depend on the keywords and detectors the snapshot was produced
+	// with, so a snapshot written under a different configuration is discarded.
+	if snapshot.CacheKey == cache.CacheKey && snapshot.Files != nil {
+		cache.previous = snapshot.Files
+	}
+	return cache, nil
+}
+
+// Lookup returns the results of a smali file from the snapshot when its
+// content hash is unchanged.
+func (c *SmaliCache) Lookup(key, hash string) (*SmaliResults, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.previous[key]
+	if !ok || entry.Hash != hash {
+		c.Scanned++
+		return nil, false
+	}
+	c.Reused++
+	return &entry.SmaliResults, true
+}
+
+func (c *SmaliCache) Store(key, hash string, results *SmaliResults) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Files[key] = cachedSmaliFile{Hash: hash, SmaliResults: *results}
+}
+
+func (c *SmaliCache) Save(path string, pretty bool) error {
+	content, err := marshalJSON(c, pretty)
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0644); err != nil {
+		return fmt.Errorf("could not write snapshot %s: %w", path, err)
+	}
+	return os.Rename(tmp, path)
+}
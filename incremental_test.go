@@ -0,0 +1,64 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/0xdeny/boolseeker/pkg/scanner"
+)
+
+func TestSmaliCacheKeywordsChangeInvalidates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	const smaliPath, hash = "smali/com/app/Root.smali", "abc123"
+
+	cache, err := LoadSmaliCache(path, EffectiveScanConfig(false, "apktool", scanner.Options{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.Store(smaliPath, hash, &SmaliResults{Findings: []Finding{{Method: "com.app.Root.isRooted()", Keywords: []string{"su"}}}})
+	if err := cache.Save(path, false); err != nil {
+		t.Fatal(err)
+	}
+
+	cache, err = LoadSmaliCache(path, EffectiveScanConfig(false, "apktool", scanner.Options{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Lookup(smaliPath, hash); !ok {
+		t.Fatal("Lookup missed under the configuration the snapshot was written with")
+	}
+
+	categories, keywords := keywordCategories, searchKeywords
+	t.Cleanup(func() { keywordCategories, searchKeywords = categories, keywords })
+	keywordCategories = slices.Clone(categories)
+	keywordCategories[0].Keywords = append(slices.Clip(keywordCategories[0].Keywords), "magiskhide")
+	searchKeywords = scanner.CategoryKeywordUnion(keywordCategories)
+
+	cache, err = LoadSmaliCache(path, EffectiveScanConfig(false, "apktool", scanner.Options{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Lookup(smaliPath, hash); ok {
+		t.Fatal("Lookup hit after the keyword list changed")
+	}
+}
+
+func TestKeywordsHashCategorySettings(t *testing.T) {
+	base := []KeywordCategory{{ID: "root", Name: "Root", Keywords: []string{"su"}, Severity: "high", Weights: map[string]int{"su": 2}}}
+	want := KeywordsHash([]string{"su"}, base)
+
+	edits := map[string]func(*KeywordCategory){
+		"id":       func(c *KeywordCategory) { c.ID = "rooting" },
+		"severity": func(c *KeywordCategory) { c.Severity = "low" },
+		"weight":   func(c *KeywordCategory) { c.Weights = map[string]int{"su": 3} },
+		"weighted": func(c *KeywordCategory) { c.Weights = nil },
+	}
+	for name, edit := range edits {
+		categories := slices.Clone(base)
+		edit(&categories[0])
+		if KeywordsHash([]string{"su"}, categories) == want {
+			t.Errorf("changing the category %s kept the keywords hash", name)
+		}
+	}
+}
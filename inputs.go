@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ExpandInputs resolves the -a value into the APK/AAB/DEX files to analyze.
+// It accepts a single file, a directory (every .apk, .aab and .dex directly
+// inside it), a glob pattern such as "builds/*.apk", an http(s) URL or - for stdin.
+func ExpandInputs(pattern string) ([]string, error) {
+	if pattern == "-" || isRemoteInput(pattern) {
+		return []string{pattern}, nil
+	}
+
+	if info, err := os.Stat(pattern); err == nil {
+		if !info.IsDir() {
+			return []string{pattern}, nil
+		}
+		var inputs []string
+		for _, ext := range []string{"*.apk", "*.aab", "*.dex"} {
+			matches, err := filepath.Glob(filepath.Join(pattern, ext))
+			if err != nil {
+				return nil, err
+			}
+			inputs = append(inputs, matches...)
+		}
+		if len(inputs) == 0 {
+			return nil, fmt.Errorf("no .apk, .aab or .dex files found in %s", pattern)
+		}
+		slices.Sort(inputs)
+		return inputs, nil
+	}
+
+	if !strings.ContainsAny(pattern, "*?[") {
+		return []string{pattern}, nil
+	}
+
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid input pattern %s: %w", pattern, err)
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no files match %s", pattern)
+	}
+	return matches, nil
+}
+
+// AppInput is one app to analyze: its base APK or App Bundle and the split
+// APKs installed alongside it. With Decoded, Base is a directory already
+// decoded by apktool, scanned as is.
+type AppInput struct {
+	Base    string
+	Splits  []string
+	Decoded bool
+}
+
+// stringsFlag collects the values of a flag given more than once.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// isSplitAPK reports whether a file is named like a split APK, e.g.
+// split_config.arm64_v8a.apk or config.en.apk as pulled with adb.
+func isSplitAPK(path string) bool {
+	name := filepath.Base(path)
+	return strings.HasSuffix(name, ".apk") && (strings.HasPrefix(name, "split_") || strings.HasPrefix(name, "config."))
+}
+
+// GroupInputs expands every -a value and groups the files into apps. When
+// any of them is a split APK, all files are the splits of a single app with
+// exactly one base APK; otherwise every file is an app of its own.
+func GroupInputs(patterns []string) ([]AppInput, error) {
+	var files []string
+	for _, pattern := range patterns {
+		expanded, err := ExpandInputs(pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range expanded {
+			if !slices.Contains(files, file) {
+				files = append(files, file)
+			}
+		}
+	}
+
+	if !slices.ContainsFunc(files, isSplitAPK) {
+		inputs := make([]AppInput, len(files))
+		for i, file := range files {
+			inputs[i] = AppInput{Base: file}
+		}
+		return inputs, nil
+	}
+
+	var input AppInput
+	var bases []string
+	for _, file := range files {
+		if isSplitAPK(file) {
+			input.Splits = append(input.Splits, file)
+		} else {
+			bases = append(bases, file)
+		}
+	}
+	if len(bases) != 1 {
+		return nil, fmt.Errorf("split APKs need exactly one base APK, found %d: %s", len(bases), strings.Join(bases, ", "))
+	}
+	input.Base = bases[0]
+	return []AppInput{input}, nil
+}
+
+// perInputPath derives the path used for one input when several are
+// analyzed, so out.txt becomes out_app-release.txt.
+func perInputPath(path, name string) string {
+	if path == "" || path == "-" {
+		return path
+	}
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "_" + name + ext
+}
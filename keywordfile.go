@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadKeywordFile reads a JSON or YAML file mapping category names to
+// keyword arrays. Categories named after a built-in category (by ID or
+// name) keep its ID and severity; any other category is reported with
+// medium severity. Categories keep the order they have in the file. A
+// keyword may be given as {keyword: ..., weight: N} to override its score.
+func LoadKeywordFile(path string) ([]KeywordCategory, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read keywords file %s: %w", path, err)
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("could not parse keywords file %s: %w", path, err)
+	}
+	if len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("invalid keywords file %s: expected a mapping of category names to keyword lists", path)
+	}
+
+	var categories []KeywordCategory
+	var empty []string
+	seen := make(map[string]bool)
+	mapping := document.Content[0]
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		name := strings.TrimSpace(mapping.Content[i].Value)
+		var entries []keywordFileEntry
+		if err := mapping.Content[i+1].Decode(&entries); err != nil {
+			return nil, fmt.Errorf("invalid keywords file %s: category %q must be a list of keywords", path, name)
+		}
+
+		category := keywordFileCategory(name)
+		if seen[category.ID] {
+			return nil, fmt.Errorf("invalid keywords file %s: category %q is defined more than once", path, name)
+		}
+		seen[category.ID] = true
+
+		for _, entry := range entries {
+			keyword := strings.TrimSpace(entry.Keyword)
+			if keyword == "" {
+				continue
+			}
+			if entry.Weight != nil && *entry.Weight < 0 {
+				return nil, fmt.Errorf("invalid keywords file %s: keyword %q has a negative weight", path, keyword)
+			}
+			category.Keywords = append(category.Keywords, keyword)
+			if entry.Weight != nil {
+				category.Weights[keyword] = *entry.Weight
+			}
+		}
+		if len(category.Keywords) == 0 {
+			empty = append(empty, name)
+		}
+		categories = append(categories, category)
+	}
+
+	if len(categories) == 0 {
+		return nil, fmt.Errorf("invalid keywords file %s: no categories defined", path)
+	}
+	if len(empty) > 0 {
+		return nil, fmt.Errorf("invalid keywords file %s: empty categories: %s", path, strings.Join(empty, ", "))
+	}
+	return categories, nil
+}
+
+// MergeKeywordCategories adds the keywords of extra to categories, as read
+// by LoadKeywordFile for --keywords-add: a category with the ID of an
+// existing one gains its keywords and weights, any other is added at the
+// end. Keywords a category already has, ignoring case, are skipped.
+func MergeKeywordCategories(categories, extra []KeywordCategory) []KeywordCategory {
+	merged := slices.Clone(categories)
+	for _, category := range extra {
+		i := slices.IndexFunc(merged, func(existing KeywordCategory) bool { return existing.ID == category.ID })
+		if i < 0 {
+			merged = append(merged, category)
+			continue
+		}
+
+		target := merged[i]
+		target.Keywords = slices.Clone(target.Keywords)
+		target.Weights = maps.Clone(target.Weights)
+		for _, keyword := range category.Keywords {
+			if !slices.ContainsFunc(target.Keywords, func(existing string) bool { return strings.EqualFold(existing, keyword) }) {
+				target.Keywords = append(target.Keywords, keyword)
+			}
+			if weight, ok := category.Weights[keyword]; ok {
+				if target.Weights == nil {
+					target.Weights = map[string]int{}
+				}
+				target.Weights[keyword] = weight
+			}
+		}
+		merged[i] = target
+	}
+	return merged
+}
+
+// keywordFileEntry is one keyword of a category: either a plain string or a
+// mapping with the keyword and its weight.
+type keywordFileEntry struct {
+	Keyword string `yaml:"keyword"`
+	Weight  *int   `yaml:"weight"`
+}
+
+func (e *keywordFileEntry) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		e.Keyword = node.Value
+		return nil
+	}
+	type plain keywordFileEntry
+	return node.Decode((*plain)(e))
+}
+
+// CommandLineKeywords returns the category --keyword adds keywords to: the
+// built-in or keyword file category called name, or a new one, and Custom
+// Keywords when name is empty.
+func CommandLineKeywords(name string, keywords []string) KeywordCategory {
+	category := KeywordCategory{ID: "custom", Name: "Custom Keywords", Severity: "medium"}
+	if name = strings.TrimSpace(name); name != "" {
+		category = keywordFileCategory(name)
+		category.Weights = nil
+	}
+	for _, keyword := range keywords {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			category.Keywords = append(category.Keywords, keyword)
+		}
+	}
+	return category
+}
+
+func keywordFileCategory(name string) KeywordCategory {
+	for _, category := range keywordCategories {
+		if strings.EqualFold(name, category.ID) || strings.EqualFold(name, category.Name) {
+			return KeywordCategory{ID: category.ID, Name: category.Name, Severity: category.Severity, Weights: map[string]int{}}
+		}
+	}
+
+	id := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, strings.ToLower(name))
+	return KeywordCategory{ID: id, Name: name, Severity: "medium", Weights: map[string]int{}}
+}
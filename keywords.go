@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/0xdeny/boolseeker/pkg/scanner"
+)
+
+// The CLI works on the types of the scanner package throughout.
+type (
+	KeywordCategory    = scanner.KeywordCategory
+	StructuralDetector = scanner.StructuralDetector
+	Rule               = scanner.Rule
+	SmaliResults       = scanner.SmaliResults
+	Finding            = scanner.Finding
+	ClassMatch         = scanner.ClassMatch
+	KeywordMatch       = scanner.KeywordMatch
+	Detection          = scanner.Detection
+	SkippedFile        = scanner.SkippedFile
+	CategorySummary    = scanner.CategorySummary
+	NativeResults      = scanner.NativeResults
+	NativeIntegrityRef = scanner.NativeIntegrityRef
+	AppFileResults     = scanner.AppFileResults
+	ResourceMatch      = scanner.ResourceMatch
+	ProtectionSDK      = scanner.ProtectionSDK
+	Verdict            = scanner.Verdict
+)
+
+// keywordCategories and structuralDetectors are the categories and
+// detectors in effect once the keywords file and --categories are applied.
+var keywordCategories = scanner.DefaultCategories()
+
+var searchKeywords = scanner.CategoryKeywordUnion(keywordCategories)
+
+var structuralDetectors = scanner.DefaultDetectors()
+
+func PrintKeywordValidation(categories []KeywordCategory) bool {
+	within, across := scanner.FindDuplicateKeywords(categories)
+
+	if len(within) == 0 && len(across) == 0 {
+		fmt.Fprintln(console, green("✔ No duplicate keywords found"))
+		return true
+	}
+
+	if len(within) > 0 {
+		fmt.Fprintln(console, yellow("✔ Keywords repeated within a category:"))
+		for _, duplicate := range within {
+			fmt.Fprintf(console, "  %s - %s\n", cyan("+ %s", duplicate.Keyword), duplicate.Categories[0])
+		}
+		fmt.Fprintln(console)
+	}
+	if len(across) > 0 {
+		fmt.Fprintln(console, yellow("✔ Keywords shared between categories:"))
+		for _, duplicate := range across {
+			fmt.Fprintf(console, "  %s - %s\n", cyan("+ %s", duplicate.Keyword), strings.Join(duplicate.Categories, ", "))
+		}
+		fmt.Fprintln(console)
+	}
+	return len(within) == 0
+}
+
+func PrintDetections(detector StructuralDetector, detections map[string][]Detection) {
+	matches := make(map[string][]string)
+	for method, methodDetections := range detections {
+		for _, detection := range methodDetections {
+			if detection.Detector == detector.Name {
+				matches[method] = detection.Targets
+			}
+		}
+	}
+
+	if len(matches) == 0 {
+		return
+	}
+
+	fmt.Fprintln(console, yellow("✔ Java boolean methods %s (weight %d):", detector.Description, detector.Weight))
+	for method, targets := range matches {
+		fmt.Fprintf(console, "  %s- %s\n", cyan("+ Java method: %s ", method), red("%s: %s", detector.TargetLabel, strings.Join(targets, ", ")))
+	}
+	fmt.Fprintln(console)
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// SetupLogging sends diagnostic logs to stderr at the given level (debug,
+// info, warn or error); verbose lowers it to debug.
+func SetupLogging(level string, verbose bool) error {
+	var logLevel slog.Level
+	if err := logLevel.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("invalid --log-level value %q (expected debug, info, warn or error)", level)
+	}
+	if verbose {
+		logLevel = slog.LevelDebug
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel})))
+	return nil
+}
@@ -2,180 +2,241 @@ package main
 
 import (
 	"archive/zip"
-	"bufio"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"os/exec"
+	"os/signal"
+	"path"
 	"path/filepath"
 	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
-	"github.com/briandowns/spinner"
+	"github.com/0xdeny/boolseeker/pkg/scanner"
+	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
 )
 
 const version = "1.0.0"
 
-func CheckApkTool() error {
-	_, err := exec.LookPath("apktool")
+// followSymlinks is set in main from --follow-symlinks; every walk of a
+// decoded directory goes through scanner.Walk with it.
+var followSymlinks bool
+
+// console receives the human-readable output; it is discarded when a
+// structured format is written to stdout instead.
+var console io.Writer = os.Stdout
+
+// status receives the human-readable output that is not a finding: decode
+// and write confirmations, the summary table and categories without
+// matches. It is discarded with --quiet as well.
+var status io.Writer = os.Stdout
+
+func soKeywordCategories(keywords []string) []KeywordCategory {
+	for i := range keywords {
+		keywords[i] = strings.TrimSpace(keywords[i])
+	}
+
+	var categories []KeywordCategory
+	assigned := make(map[string]struct{})
+	for _, category := range keywordCategories {
+		var matched []string
+		for _, keyword := range category.Keywords {
+			if slices.Contains(keywords, keyword) {
+				matched = append(matched, keyword)
+				assigned[keyword] = struct{}{}
+			}
+		}
+		if len(matched) > 0 {
+			categories = append(categories, KeywordCategory{ID: category.ID, Name: category.Name, Keywords: matched, Severity: category.Severity, Weights: category.Weights})
+		}
+	}
+
+	var custom []string
+	for _, keyword := range keywords {
+		if _, ok := assigned[keyword]; !ok && keyword != "" {
+			custom = append(custom, keyword)
+		}
+	}
+	if len(custom) > 0 {
+		categories = append(categories, KeywordCategory{ID: "custom", Name: "Custom Keywords", Keywords: custom, Severity: "low"})
+	}
+	return categories
+}
+
+// Apktool is the apktool executable APKs are decoded with and the extra
+// arguments passed to apktool d, such as --no-res.
+type Apktool struct {
+	Path string
+	Args []string
+}
+
+// ParseApktoolArgs splits the --apktool-args value on spaces. The output
+// directory is chosen by boolseeker, so -o and --output are rejected.
+func ParseApktoolArgs(value string) ([]string, error) {
+	args := strings.Fields(value)
+	for _, arg := range args {
+		name, _, _ := strings.Cut(arg, "=")
+		if name == "-o" || name == "--output" {
+			return nil, fmt.Errorf("%s is set by boolseeker; use --out-dir to keep the decoded APK", name)
+		}
+	}
+	return args, nil
+}
+
+// lookPathScript resolves path like exec.LookPath. On Windows, where apktool
+// is usually installed as apktool.bat, a name without an extension is also
+// looked up with .bat and .cmd, whatever PATHEXT says.
+func lookPathScript(path string) (string, error) {
+	resolved, err := exec.LookPath(path)
+	if err == nil || runtime.GOOS != "windows" || filepath.Ext(path) != "" {
+		return resolved, err
+	}
+	for _, ext := range []string{".bat", ".cmd"} {
+		if script, scriptErr := exec.LookPath(path + ext); scriptErr == nil {
+			return script, nil
+		}
+	}
+	return "", err
+}
+
+// CheckApkTool resolves path, either a command name looked up in PATH or the
+// path of an apktool executable, and fails when it cannot be run.
+func CheckApkTool(path string) (string, error) {
+	resolved, err := lookPathScript(path)
 	if err != nil {
-		return fmt.Errorf("\033[31m✖️ apktool is not installed or not found in PATH\033[0m")
+		if path == "apktool" {
+			return "", fmt.Errorf("✖️ apktool is not installed or not found in PATH")
+		}
+		return "", fmt.Errorf("✖️ apktool not found or not executable at %s", path)
 	}
-	return nil
+	return resolved, nil
 }
 
-func isAPKFile(apkFile string) (bool, error) {
+// validateAPK returns why apkFile is not an APK, or nil: it is a directory,
+// it cannot be opened as a zip archive, or it lacks AndroidManifest.xml or,
+// unless it is a split APK, a classes.dex, which some packagers keep in a
+// subdirectory. The zip reader's own error is only included at debug level.
+func validateAPK(apkFile string) error {
 	fileInfo, err := os.Stat(apkFile)
 	if err != nil {
-		return false, fmt.Errorf("could not stat file: %w", err)
+		return fmt.Errorf("could not stat file: %w", err)
 	}
 
 	if fileInfo.IsDir() {
-		return false, nil
+		return fmt.Errorf("it is a directory")
 	}
 
 	zipReader, err := zip.OpenReader(apkFile)
 	if err != nil {
-		return false, nil
+		if slog.Default().Enabled(context.Background(), slog.LevelDebug) {
+			return fmt.Errorf("it cannot be opened as a zip archive: %w", err)
+		}
+		return fmt.Errorf("it cannot be opened as a zip archive (re-run with --verbose for the reason)")
 	}
 	defer zipReader.Close()
 
-	requiredFiles := map[string]bool{
-		"AndroidManifest.xml": false,
-		"classes.dex":         false,
-	}
-
+	hasManifest, hasDex := false, false
 	for _, file := range zipReader.File {
-		if _, found := requiredFiles[file.Name]; found {
-			requiredFiles[file.Name] = true
+		switch {
+		case file.Name == "AndroidManifest.xml":
+			hasManifest = true
+		case path.Base(file.Name) == "classes.dex":
+			hasDex = true
 		}
 	}
 
-	for _, found := range requiredFiles {
-		if !found {
-			return false, nil
-		}
+	var missing []string
+	if !hasManifest {
+		missing = append(missing, "AndroidManifest.xml")
 	}
-
-	return true, nil
+	// Split APKs often carry only resources or native libraries.
+	if !hasDex && !isSplitAPK(apkFile) {
+		missing = append(missing, "classes.dex")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("the archive has no %s", strings.Join(missing, " or "))
+	}
+	return nil
 }
 
-func DecodeAPK(apkFile, outputDirectory string, s *spinner.Spinner) error {
+// DecodeAPK decodes an APK or App Bundle with apktool. The apktool and
+// bundletool processes are killed when ctx is cancelled.
+func DecodeAPK(ctx context.Context, apktool Apktool, apkFile, outputDirectory string, progress *Progress) error {
 	if _, err := os.Stat(apkFile); os.IsNotExist(err) {
-		return fmt.Errorf("\033[31m✖ The provided file does not exist: %s\033[0m", apkFile)
+		return fmt.Errorf("✖ The provided file does not exist: %s", apkFile)
 	}
 
-	isValidAPK, err := isAPKFile(apkFile)
+	isBundle, err := isAABFile(apkFile)
 	if err != nil {
-		return fmt.Errorf("\033[31m✖ The provided file is not a valid APK: %s\033[0m", apkFile)
+		return fmt.Errorf("✖ The provided file is not a valid APK: %s", apkFile)
+	}
+	if isBundle {
+		if err := CheckBundletool(); err != nil {
+			return err
+		}
+		workDirectory, err := os.MkdirTemp("", "boolseeker-aab-")
+		if err != nil {
+			return fmt.Errorf("✖ Error converting App Bundle: %w", err)
+		}
+		defer os.RemoveAll(workDirectory)
+
+		progress.Status("Converting App Bundle to a universal APK: %s...", apkFile)
+		universalAPK, err := buildUniversalAPK(ctx, apkFile, workDirectory)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("✖ Error converting App Bundle: %w", err)
+		}
+		apkFile = universalAPK
 	}
 
-	if !isValidAPK {
-		return fmt.Errorf("\033[31m✖ The provided file is not a valid APK: %s\033[0m", apkFile)
+	if err := validateAPK(apkFile); err != nil {
+		return fmt.Errorf("✖ The provided file is not a valid APK: %s: %v", apkFile, err)
 	}
 
-	s.Suffix = fmt.Sprintf(" Decompiling APK: %s...", apkFile)
-	cmd := exec.Command("apktool", "d", apkFile, "-o", outputDirectory)
+	progress.Status("Decompiling APK: %s...", apkFile)
+	args := slices.Concat([]string{"d"}, apktool.Args, []string{filepath.Clean(apkFile), "-o", filepath.Clean(outputDirectory)})
+	cmd := exec.CommandContext(ctx, apktool.Path, args...)
 	cmd.Stdout = nil
 	cmd.Stderr = nil
+	slog.Debug("running apktool", "args", cmd.Args)
 	err = cmd.Run()
 
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
 	if err != nil {
-		return fmt.Errorf("\033[31m✖ Error decompiling APK: %w\033[0m", err)
+		return fmt.Errorf("✖ Error decompiling APK: %w", err)
 	}
 	return nil
 }
 
-func SearchKeywordsInMethod(methodContent string) ([]string, bool) {
-	keywords := []string{"ro.hardware", "ro.kernel.qemu", "ro.product.device", "ro.build.product", "ro.product.model", "ro.build.fingerprint", "/dev/qemu_trace", "/system/bin/netcfg", "magisk", "root", "test-keys", "superuser", "Superuser", "daemonsu", "99SuperSUDaemon", ".has_su_daemon", "genymotion", "emulator", "nox", "27042", "frida", "27043", "FridaGadget", "xposed", "MessageDigest", "getPackageInfo", "signature", "/system/app/Superuser.apk", "/system/xbin/su", "com.noshufou.android.su.elite", "com.noshufou.android.su", "com.yellowes.su", "com.koushikdutta.superuser", "com.thirdparty.superuser", "eu.chainfire.supersu", "/system/usr/we-need-root", "ueventd.android_x86.rc", "x86.prop", "ueventd.ttVM_x86.rc", "init.ttVM_x86.rc", "fstab.ttVM_x86", "fstab.vbox86", "init.vbox86.rc", "ueventd.vbox86.rc", "/dev/socket/qemud", "/dev/qemu_pipe", "/system/lib/libc_malloc_debug_qemu.so", "/sys/qemu_trace", "/system/bin/qemu-props", "/dev/socket/genyd", "/dev/socket/baseband_genyd", "/proc/tty/drivers", "/proc/cpuinfo", "com.koushikdutta.rommanager", "com.koushikdutta.rommanager.license", "com.dimonvideo.luckypatcher", "com.chelpus.lackypatch", "com.ramdroid.appquarantine", "com.ramdroid.appquarantinepro", "com.devadvance.rootcloak", "com.devadvance.rootcloakplus", "de.robv.android.xposed.installer", "com.saurik.substrate", "com.zachspong.temprootremovejb", "com.amphoras.hidemyroot", "com.amphoras.hidemyrootadfree", "com.formyhm.hiderootPremium", "com.formyhm.hideroot", "me.phh.superuser", "eu.chainfire.supersu.pro", "com.kingouser.com", "com.android.vending.billing.InAppBillingService.COIN", "su", "busybox", "supersu", "Superuser.apk", "KingoUser.apk", "SuperSu.apk", "ro.build.selinux", "ro.debuggable", "service.adb.root", "ro.secure", "com.topjohnwu.magisk", "/data/local/bin/su", "/data/local/su", "/data/local/xbin/su", "/dev/com.koushikdutta.superuser.daemon/", "/sbin/su", "/system/bin/failsafe/su", "/su/bin/su", "/system/sd/xbin/su", "/system/xbin/daemonsu", "/system/sbin/su", "/vendor/bin/su", "/cache/su", "/data/su", "/dev/su", "/system/bin/.ext/su", "/system/usr/we-need-root/su", "/system/app/Kinguser.apk", "/data/adb/magisk", "/sbin/.magisk", "/cache/.disable_magisk", "/dev/.magisk.unblock", "/cache/magisk.log", "/data/adb/magisk.img", "/data/adb/magisk.db", "/data/adb/magisk_simple", "/init.magisk.rc", "/system/xbin/ku.sud", "/data/adb/ksu", "/data/adb/ksud", "me.weishu.kernelsu", "init.svc.qemud", "init.svc.qemu-props", "qemu.hw.mainkeys", "qemu.sf.fake_camera", "qemu.sf.lcd_density", "ro.bootloader", "ro.bootmode", "ro.kernel.android.qemud", "ro.kernel.qemu.gles", "ro.product.name", "ro.serialno", "geny"}
-
-	foundKeywords := []string{}
-
-	for _, keyword := range keywords {
-		if strings.Contains(strings.ToLower(methodContent), keyword) {
-			foundKeywords = append(foundKeywords, keyword)
-		}
+// DecodeDex is the --engine dex counterpart of DecodeAPK, decoding with
+// scanner.DecodeDex once apkFile is known to be an APK.
+func DecodeDex(apkFile, outputDirectory string) error {
+	if _, err := os.Stat(apkFile); os.IsNotExist(err) {
+		return fmt.Errorf("✖ The provided file does not exist: %s", apkFile)
 	}
 
-	return foundKeywords, len(foundKeywords) > 0
-}
-
-func FindBooleanMethodsInSmali(directory string) ([]string, map[string][]string, error) {
-	var booleanMethods []string
-	booleanMethodsWithKeywords := make(map[string][]string)
-	methodPattern := regexp.MustCompile(`\.method.* (\w+)\(\)Z`)
-	endMethodPattern := regexp.MustCompile(`\.end method`)
-
-	err := filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		if !info.IsDir() && strings.HasSuffix(info.Name(), ".smali") {
-			file, err := os.Open(path)
-			if err != nil {
-				return err
-			}
-			defer file.Close()
-
-			relativePath, err := filepath.Rel(directory, path)
-
-			if err != nil {
-				return err
-			}
-
-			className := strings.TrimSuffix(relativePath, ".smali")
-			className = strings.ReplaceAll(className, "/", ".")
-			className = strings.ReplaceAll(className, "$", ".")
-
-			reader := bufio.NewReaderSize(file, 1<<20)
-			var currentMethod string
-			var inMethod bool
-			var methodContent strings.Builder
-
-			for {
-				line, err := reader.ReadString('\n')
-
-				if err != nil {
-					if err == io.EOF {
-						break
-					}
-					return err
-				}
-
-				if methodMatch := methodPattern.FindStringSubmatch(line); methodMatch != nil {
-					currentMethod = methodMatch[1]
-					inMethod = true
-					methodContent.Reset()
-				}
-
-				if inMethod {
-					methodContent.WriteString(line)
-				}
-
-				if inMethod && endMethodPattern.MatchString(line) {
-					inMethod = false
-					fullMethodName := fmt.Sprintf("%s.%s()", className, currentMethod)
-
-					foundKeywords, found := SearchKeywordsInMethod(methodContent.String())
-					if found {
-						booleanMethods = append(booleanMethods, fullMethodName)
-						booleanMethodsWithKeywords[fullMethodName] = foundKeywords
-					} else {
-						booleanMethods = append(booleanMethods, fullMethodName)
-					}
-				}
-			}
-		}
-		return nil
-	})
+	if err := validateAPK(apkFile); err != nil {
+		return fmt.Errorf("✖ The provided file is not a valid APK: %s: %v", apkFile, err)
+	}
 
-	if err != nil {
-		return nil, nil, err
+	if err := scanner.DecodeDex(apkFile, outputDirectory); err != nil {
+		return fmt.Errorf("✖ Error decoding APK: %w", err)
 	}
-	return booleanMethods, booleanMethodsWithKeywords, nil
+	return nil
 }
 
 func CleanUp(directory string) {
@@ -184,7 +245,7 @@ func CleanUp(directory string) {
 	if os.IsNotExist(err) {
 		return
 	} else if err != nil {
-		fmt.Printf("\033[31m✖️ Error checking directory %s: %v\n", directory, err)
+		fmt.Fprintln(console, red("✖️ Error checking directory %s: %v", directory, err))
 		return
 	}
 
@@ -194,319 +255,1369 @@ func CleanUp(directory string) {
 
 	err = os.RemoveAll(directory)
 	if err != nil {
-		fmt.Printf("\033[31m✖️ Error cleaning up directory %s: %v\n", directory, err)
+		fmt.Fprintln(console, red("✖️ Error cleaning up directory %s: %v", directory, err))
 	} else {
-		fmt.Printf("\033[32m✔ Cleaned up directory %s\n", directory)
+		fmt.Fprintln(status, green("✔ Cleaned up directory %s", directory))
 	}
 }
 
+// helpRequested reports whether -h or --help appears among the arguments
+// before a "--" terminator.
+func helpRequested(args []string) bool {
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || (name != "h" && name != "help") {
+			continue
+		}
+		if enabled, err := strconv.ParseBool(value); !hasValue || (err == nil && enabled) {
+			return true
+		}
+	}
+	return false
+}
+
+// CustomUsage prints the help text to stdout. Every flag is listed: any flag
+// missing from the hand-written list is appended with its own description.
 func CustomUsage() {
-	fmt.Println("Usage of boolseeker:")
-	fmt.Println("  -a, --apk string")
-	fmt.Println("        Path to the APK file to decode and analyze (required)")
-	fmt.Println("  -o, --output string")
-	fmt.Println("        Path to the output file for boolean method names (required)")
-	fmt.Println("  -so")
-	fmt.Println("        Enable searching in .so files")
-	fmt.Println("  --version")
-	fmt.Println("        Display the current version of boolseeker")
-	fmt.Println("  -h, --help string")
-	fmt.Println("        Display help information")
+	var usage strings.Builder
+	fmt.Fprintln(&usage, "Usage of boolseeker:")
+	fmt.Fprintln(&usage, "  -a, --apk string")
+	fmt.Fprintln(&usage, "        Path to the APK, App Bundle (.aab) or DEX file, a directory or a glob of them, an http(s) URL, or - for stdin; repeat for split APKs (required)")
+	fmt.Fprintln(&usage, "  --decoded-dir string")
+	fmt.Fprintln(&usage, "        Path to an app already decoded with apktool, scanned as is without apktool; repeat to scan several")
+	fmt.Fprintln(&usage, "  --follow-symlinks")
+	fmt.Fprintln(&usage, "        Walk into symbolic links to directories in the decoded app, walking each directory once so links cannot repeat or loop (default not followed)")
+	fmt.Fprintln(&usage, "  -o, --output string")
+	fmt.Fprintln(&usage, "        Path to the output file for boolean method names (required)")
+	fmt.Fprintln(&usage, "  --descriptor-format")
+	fmt.Fprintln(&usage, "        Report methods as JVM descriptors (Lcom/app/Class;->method()Z) instead of dotted names")
+	fmt.Fprintln(&usage, "  --match-args")
+	fmt.Fprintln(&usage, "        Also report boolean methods taking arguments or returning java.lang.Boolean, with their full signature")
+	fmt.Fprintln(&usage, "  --return-types string")
+	fmt.Fprintln(&usage, "        Comma-separated return type descriptors of the methods to scan, e.g. Z,V,I (default Z)")
+	fmt.Fprintln(&usage, "  --include-package string")
+	fmt.Fprintln(&usage, "        Comma-separated package globs, e.g. com/app or com.app.*; only classes in matching packages are scanned")
+	fmt.Fprintln(&usage, "  --exclude-package string")
+	fmt.Fprintln(&usage, "        Comma-separated package globs, e.g. com/google,androidx; classes in matching packages are not scanned")
+	fmt.Fprintln(&usage, "  --case-sensitive")
+	fmt.Fprintln(&usage, "        Match keywords case-sensitively")
+	fmt.Fprintln(&usage, "  --word-boundary")
+	fmt.Fprintln(&usage, "        Only match keywords delimited by non-identifier characters (su no longer matches subscribe), in smali and .so files alike")
+	fmt.Fprintln(&usage, "  --literals-only")
+	fmt.Fprintln(&usage, "        Only match keywords inside const-string literals instead of whole smali lines")
+	fmt.Fprintln(&usage, "  --reassemble-strings")
+	fmt.Fprintln(&usage, "        Also match keywords against strings split across const-string literals or built char by char")
+	fmt.Fprintln(&usage, "  --min-score int")
+	fmt.Fprintln(&usage, "        Only report boolean methods whose keyword and detection weights add up to at least this score")
+	fmt.Fprintln(&usage, "  --ignore string")
+	fmt.Fprintln(&usage, "        Path to a file of method names or glob patterns, one per line, to leave out of the results")
+	fmt.Fprintln(&usage, "  --include-methods string")
+	fmt.Fprintln(&usage, "        Which boolean methods to write to the output file: all, matched or none (default matched)")
+	fmt.Fprintln(&usage, "  --unmatched-out string")
+	fmt.Fprintln(&usage, "        Path to write the boolean methods without any keyword or detection to, one per line")
+	fmt.Fprintln(&usage, "  -so")
+	fmt.Fprintln(&usage, "        Enable searching in .so files")
+	fmt.Fprintln(&usage, "  --so-keywords string")
+	fmt.Fprintln(&usage, "        Comma-separated keywords to search for in .so files instead of the built-in categories")
+	fmt.Fprintln(&usage, "  --no-so-default-keywords")
+	fmt.Fprintln(&usage, "        Require --so-keywords instead of falling back to the built-in categories for .so files")
+	fmt.Fprintln(&usage, "  --strings")
+	fmt.Fprintln(&usage, "        Search .so files in the output of the system strings tool instead of parsing them in process (falls back when it is not in PATH)")
+	fmt.Fprintln(&usage, "  --abi string")
+	fmt.Fprintln(&usage, "        Comma-separated ABIs whose .so files to search, e.g. arm64-v8a (default all)")
+	fmt.Fprintln(&usage, "  --all-abis")
+	fmt.Fprintln(&usage, "        Search every copy of a library shipped for several ABIs instead of only one")
+	fmt.Fprintln(&usage, "  --group-abis")
+	fmt.Fprintln(&usage, "        Report the copies of a library shipped for several ABIs as one entry, listing the ABIs that matched")
+	fmt.Fprintln(&usage, "  --max-filesize int")
+	fmt.Fprintln(&usage, "        Skip .so and asset files larger than this many MB, e.g. bundled ML models; 0 disables the limit (default 200)")
+	fmt.Fprintln(&usage, "  --trace-early-init")
+	fmt.Fprintln(&usage, "        Trace calls from Application/ContentProvider startup methods and highlight the boolean methods they reach")
+	fmt.Fprintln(&usage, "  --group-by string")
+	fmt.Fprintln(&usage, "        How to list the findings in the text output: method, or class to aggregate methods and keywords per class (default method)")
+	fmt.Fprintln(&usage, "  --callers")
+	fmt.Fprintln(&usage, "        List the methods that invoke each boolean method with keywords or detections, to tell wired-in checks from dead code")
+	fmt.Fprintln(&usage, "  --scan-resources")
+	fmt.Fprintln(&usage, "        Scan decoded resources: link boolean methods to checksums embedded in them and match keywords against string resources")
+	fmt.Fprintln(&usage, "  --with-context int")
+	fmt.Fprintln(&usage, "        Write this many lines of smali around each keyword hit below each method in the output file, like grep -C")
+	fmt.Fprintln(&usage, "  --scan-class-metadata")
+	fmt.Fprintln(&usage, "        Also match keywords against field declarations and class-level annotations, reported per class")
+	fmt.Fprintln(&usage, "  --out-dir string")
+	fmt.Fprintln(&usage, "        Directory to decode APKs into and keep afterwards (default: a temporary directory that is removed)")
+	fmt.Fprintln(&usage, "  --cache-dir string")
+	fmt.Fprintln(&usage, "        Directory to keep decoded APKs in, keyed by their SHA-256, so scanning the same APK again skips decoding")
+	fmt.Fprintln(&usage, "  --no-cache")
+	fmt.Fprintln(&usage, "        Decode APKs even when --cache-dir is set, e.g. in the config file")
+	fmt.Fprintln(&usage, "  --keep")
+	fmt.Fprintln(&usage, "        Keep the decoded APK after the scan instead of removing it")
+	fmt.Fprintln(&usage, "  --scan-manifest")
+	fmt.Fprintln(&usage, "        Search AndroidManifest.xml for keywords and integrity signals such as REQUEST_INSTALL_PACKAGES")
+	fmt.Fprintln(&usage, "  --scan-assets")
+	fmt.Fprintln(&usage, "        Search the files under assets/ for keywords and integrity signals")
+	fmt.Fprintln(&usage, "  --incremental string")
+	fmt.Fprintln(&usage, "        Path to a snapshot file; only smali files changed since the snapshot are re-scanned")
+	fmt.Fprintln(&usage, "  --diff string")
+	fmt.Fprintln(&usage, "        Path to a baseline APK to scan as well; reports the boolean method findings added, removed or changed since it")
+	fmt.Fprintln(&usage, "  --on-finding string")
+	fmt.Fprintln(&usage, "        Shell command to run when matches are found; the findings are piped to its stdin as JSON and its output goes to stderr")
+	fmt.Fprintln(&usage, "  --on-finding-timeout duration")
+	fmt.Fprintln(&usage, "        Maximum time the --on-finding command may run (default 30s)")
+	fmt.Fprintln(&usage, "  --webhook string")
+	fmt.Fprintln(&usage, "        URL to POST the findings to as JSON when the scan completes")
+	fmt.Fprintln(&usage, "  --webhook-secret string")
+	fmt.Fprintln(&usage, "        Secret used to sign webhook bodies (X-Boolseeker-Signature); defaults to $BOOLSEEKER_WEBHOOK_SECRET")
+	fmt.Fprintln(&usage, "  --webhook-timeout duration")
+	fmt.Fprintln(&usage, "        Timeout for each webhook delivery attempt (default 10s)")
+	fmt.Fprintln(&usage, "  --webhook-retries int")
+	fmt.Fprintln(&usage, "        Number of times to retry a failed webhook delivery (default 3)")
+	fmt.Fprintln(&usage, "  --aggregate-out string")
+	fmt.Fprintln(&usage, "        Path to write a JSON summary across all scanned apps to: how many apps hit each category, keyword and method")
+	fmt.Fprintln(&usage, "  --db string")
+	fmt.Fprintln(&usage, "        Path to a SQLite database to add the app, its matched methods and keyword hits to, created if needed (requires sqlite3 in PATH)")
+	fmt.Fprintln(&usage, "  --summary-line, --emit-summary-stderr")
+	fmt.Fprintln(&usage, "        Print a single BOOLSEEKER_SUMMARY key=value line with the per-category counts to stderr")
+	fmt.Fprintln(&usage, "  --format string")
+	fmt.Fprintln(&usage, "        Output format for the results on stdout: text, json, ndjson, sarif, html, csv or md (default text)")
+	fmt.Fprintln(&usage, "  --json-out string")
+	fmt.Fprintln(&usage, "        Path to write the JSON report to, in addition to the text output")
+	fmt.Fprintln(&usage, "  --html-out string")
+	fmt.Fprintln(&usage, "        Path to write a self-contained HTML report to, in addition to the text output")
+	fmt.Fprintln(&usage, "  --json-pretty")
+	fmt.Fprintln(&usage, "        Indent JSON output for readability instead of writing it compactly")
+	fmt.Fprintln(&usage, "  --engine string")
+	fmt.Fprintln(&usage, "        How to decode the APK: apktool, or dex to parse classes*.dex directly without apktool, writing every class and method, not only those returning boolean, as stub smali (default apktool)")
+	fmt.Fprintln(&usage, "  --apktool-path string")
+	fmt.Fprintln(&usage, "        apktool executable to decode APKs with, looked up in PATH unless it is a path (default apktool)")
+	fmt.Fprintln(&usage, "  --apktool-args string")
+	fmt.Fprintln(&usage, "        Extra space-separated arguments for apktool d, e.g. \"--no-res -f\"")
+	fmt.Fprintln(&usage, "  --smali-only")
+	fmt.Fprintln(&usage, "        Skip decoding resources (apktool --no-res) for a faster decode; cannot be combined with --scan-manifest or --scan-resources")
+	fmt.Fprintln(&usage, "  --fail-on string")
+	fmt.Fprintln(&usage, "        Comma-separated category IDs (or any) whose findings make boolseeker exit with code 2 (Java) or 3 (.so)")
+	fmt.Fprintln(&usage, "  --verdict-threshold string")
+	fmt.Fprintln(&usage, "        Matched methods from which a category counts as present in the verdict, e.g. \"3,root=5\" (default 3)")
+	fmt.Fprintln(&usage, "  --timeout duration")
+	fmt.Fprintln(&usage, "        Maximum time for the whole run, e.g. 10m; apktool and the scan are stopped when it expires (default no limit)")
+	fmt.Fprintln(&usage, "  --workers int")
+	fmt.Fprintln(&usage, "        Number of smali and .so files to scan in parallel (default: number of CPUs)")
+	fmt.Fprintln(&usage, "  --categories string")
+	fmt.Fprintln(&usage, "        Comma-separated IDs of the keyword categories and structural detectors to run, e.g. root,frida (default all)")
+	fmt.Fprintln(&usage, "  --keywords string")
+	fmt.Fprintln(&usage, "        Path to a JSON or YAML file mapping category names to keyword lists (default built-in keywords)")
+	fmt.Fprintln(&usage, "  --keywords-add string")
+	fmt.Fprintln(&usage, "        Path to a JSON or YAML file mapping category names to keywords to add to the built-in ones")
+	fmt.Fprintln(&usage, "  --rules string")
+	fmt.Fprintln(&usage, "        Path to a YAML file of rules combining conditions over method content, run in addition to the built-in ones")
+	fmt.Fprintln(&usage, "  --keyword string")
+	fmt.Fprintln(&usage, "        Keyword to search for in addition to the active ones; repeat for several")
+	fmt.Fprintln(&usage, "  --keyword-category string")
+	fmt.Fprintln(&usage, "        Category to add the --keyword keywords to, a built-in one such as root or a new one (default Custom Keywords)")
+	fmt.Fprintln(&usage, "  --validate-keywords")
+	fmt.Fprintln(&usage, "        Report duplicate keywords within and across categories and exit")
+	fmt.Fprintln(&usage, "  --config string")
+	fmt.Fprintln(&usage, "        Path to a YAML file with default flag values (default ./.boolseeker.yaml when present)")
+	fmt.Fprintln(&usage, "  --no-color")
+	fmt.Fprintln(&usage, "        Disable colored output (also disabled when stdout is not a terminal or NO_COLOR is set)")
+	fmt.Fprintln(&usage, "  --quiet")
+	fmt.Fprintln(&usage, "        Only print findings, warnings and errors: no spinners, progress counters, decode messages or categories without matches")
+	fmt.Fprintln(&usage, "  --silent")
+	fmt.Fprintln(&usage, "        Print nothing but errors, relying on the exit code and the output files")
+	fmt.Fprintln(&usage, "  -v, --verbose")
+	fmt.Fprintln(&usage, "        Print additional diagnostic output and debug logs")
+	fmt.Fprintln(&usage, "  --log-level string")
+	fmt.Fprintln(&usage, "        Level of the diagnostic logs written to stderr: debug, info, warn or error (default warn)")
+	fmt.Fprintln(&usage, "  --print-schema")
+	fmt.Fprintln(&usage, "        Print the JSON Schema of the JSON report, or of an ndjson line with --format ndjson, and exit")
+	fmt.Fprintln(&usage, "  --self-test")
+	fmt.Fprintln(&usage, "        Scan bundled sample smali, check that the expected categories are found and that apktool is installed, and exit")
+	fmt.Fprintln(&usage, "  --version")
+	fmt.Fprintln(&usage, "        Display the version of boolseeker, the Go version and commit it was built from, and the apktool version")
+	fmt.Fprintln(&usage, "  -h, --help")
+	fmt.Fprintln(&usage, "        Display help information")
+	writeUndocumentedFlags(&usage)
+	fmt.Fprintln(&usage)
+	fmt.Fprintln(&usage, "Options are taken from the command line first, then from the config file, then from the built-in defaults.")
+	fmt.Print(usage.String())
 }
 
-func SearchInSoFiles(directory string, keywords []string) error {
-	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
-	s.Color("red", "yellow", "blue", "green")
+// writeUndocumentedFlags appends the flags that have no entry in usage yet,
+// in the format of the flag package, so the help text cannot fall behind the
+// flags that exist.
+func writeUndocumentedFlags(usage *strings.Builder) {
+	documented := make(map[string]bool)
+	for _, line := range strings.Split(usage.String(), "\n") {
+		if !strings.HasPrefix(line, "  -") {
+			continue
+		}
+		for _, field := range strings.Fields(line) {
+			if !strings.HasPrefix(field, "-") {
+				break
+			}
+			documented[strings.TrimLeft(strings.TrimSuffix(field, ","), "-")] = true
+		}
+	}
 
-	s.Start()
-	s.Suffix = " Searching for keywords in native functions within .so files..."
+	flag.VisitAll(func(f *flag.Flag) {
+		if _, alias := flagAliases[f.Name]; alias || documented[f.Name] {
+			return
+		}
+		name, description := flag.UnquoteUsage(f)
+		fmt.Fprintln(usage, strings.TrimRight("  --"+f.Name+" "+name, " "))
+		fmt.Fprintln(usage, "        "+description)
+	})
+}
 
-	foundKeywords := map[string][]string{}
+// sortedNativeFiles returns the .so files of a NativeResults map in order.
+func sortedNativeFiles[V any](files map[string]V) []string {
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+	return paths
+}
 
-	err := filepath.Walk(filepath.Join(directory, "lib"), func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			if os.IsNotExist(err) {
-				return nil
-			}
-			return err
-		}
+// nativeFileLabel is how a .so file is printed: its path, or with
+// --group-abis its name followed by the ABIs that matched.
+func nativeFileLabel(nativeResults *NativeResults, filePath string) string {
+	if abis := nativeResults.ABIs[filePath]; len(abis) > 0 {
+		return fmt.Sprintf("%s (%s)", filePath, strings.Join(abis, ", "))
+	}
+	return filePath
+}
 
-		if !info.IsDir() && strings.HasSuffix(info.Name(), ".so") {
-			content, err := os.ReadFile(path)
-			if err != nil {
-				return err
+func PrintNativeIntegrity(nativeResults *NativeResults) {
+	if len(nativeResults.Integrity) == 0 {
+		return
+	}
+
+	fmt.Fprintln(console, yellow("✔ .so files embedding expected hashes (native-integrity):"))
+	for _, filePath := range sortedNativeFiles(nativeResults.Integrity) {
+		refs := nativeResults.Integrity[filePath]
+		var descriptions []string
+		for _, ref := range refs {
+			if ref.Target != "" {
+				descriptions = append(descriptions, fmt.Sprintf("%s -> %s", ref.Digest, ref.Target))
+			} else {
+				descriptions = append(descriptions, ref.Digest)
 			}
+		}
+		fmt.Fprintf(console, "  %s %s%s\n", cyan("+ %s", nativeFileLabel(nativeResults, filePath)), white("- "), red("Digests found: %s", strings.Join(descriptions, ", ")))
+	}
+	fmt.Fprintln(console)
+}
 
-			for _, keyword := range keywords {
-				if strings.Contains(strings.ToLower(string(content)), strings.ToLower(keyword)) {
-					relativePath := strings.TrimPrefix(path, filepath.Join(directory))
-					foundKeywords[relativePath] = append(foundKeywords[relativePath], keyword)
+func PrintNativeCategoryMatches(category string, categoryKeywords []string, nativeResults *NativeResults) {
+	filesWithKeywords := scanner.FilterCategoryKeywords(categoryKeywords, nativeResults.Keywords)
+
+	if len(filesWithKeywords) > 0 {
+		fmt.Fprintln(console, yellow("✔ .so files containing keywords about %s:", category))
+		for _, filePath := range sortedNativeFiles(filesWithKeywords) {
+			keywords := filesWithKeywords[filePath]
+			fmt.Fprintf(console, "  %s %s%s\n", cyan("+ %s", nativeFileLabel(nativeResults, filePath)), white("- "), red("Keywords found: %s", strings.Join(keywords, ", ")))
+			for _, match := range nativeResults.Matches[filePath] {
+				if match.Source != scanner.NativeSourceRaw && slices.Contains(keywords, match.Keyword) {
+					fmt.Fprintf(console, "      %s in %s: %s\n", match.Keyword, match.Source, match.Value)
 				}
 			}
 		}
+		fmt.Fprintln(console)
+	} else {
+		fmt.Fprintln(status, red("X No keywords about %s found in .so files.", category))
+		fmt.Fprintln(status)
+	}
+}
 
-		return nil
-	})
-
-	s.Stop()
+// PrintKeywordLocations prints each smali line holding one of the keywords
+// once, in file order, followed by the keywords found on it.
+func PrintKeywordLocations(matches []KeywordMatch, keywords []string) {
+	var lines []KeywordMatch
+	keywordsByLine := make(map[int][]string)
+	for _, match := range matches {
+		if match.Line == 0 || !slices.Contains(keywords, match.Keyword) {
+			continue
+		}
+		if _, seen := keywordsByLine[match.Line]; !seen {
+			lines = append(lines, match)
+		}
+		keywordsByLine[match.Line] = append(keywordsByLine[match.Line], match.Keyword)
+	}
+	slices.SortFunc(lines, func(a, b KeywordMatch) int { return a.Line - b.Line })
 
-	if err != nil {
-		return err
+	for _, line := range lines {
+		fmt.Fprintf(console, "      %s:%d: %s (%s)\n", line.File, line.Line, line.Context, strings.Join(keywordsByLine[line.Line], ", "))
 	}
+}
 
-	if len(foundKeywords) > 0 {
-		fmt.Println("\033[33m✔ Keywords found in the following .so files:\033[0m")
-		for filePath, keywords := range foundKeywords {
-			fmt.Printf("  \033[36m+ %s\033[0m \033[37m- \033[31mKeywords found: %s\033[0m\n", filePath, strings.Join(keywords, ", "))
+// PrintCategoryMatches lists the boolean methods matching a category, the
+// highest scoring first.
+func PrintCategoryMatches(category string, categoryKeywords []string, booleanMethodsWithKeywords map[string][]string, keywordMatches map[string][]KeywordMatch, scores map[string]int) {
+	methodsWithKeywords := scanner.FilterCategoryKeywords(categoryKeywords, booleanMethodsWithKeywords)
+
+	if len(methodsWithKeywords) > 0 {
+		methods := make([]string, 0, len(methodsWithKeywords))
+		for method := range methodsWithKeywords {
+			methods = append(methods, method)
+		}
+		slices.SortFunc(methods, func(a, b string) int {
+			if scores[a] != scores[b] {
+				return scores[b] - scores[a]
+			}
+			return strings.Compare(a, b)
+		})
+
+		fmt.Fprintln(console, yellow("✔ Java boolean methods containing keywords about %s:", category))
+		for _, method := range methods {
+			keywords := methodsWithKeywords[method]
+			fmt.Fprintf(console, "  %s- %s\n", cyan("+ Java method: %s (score %d) ", method, scores[method]), red("Keywords found: %s", strings.Join(keywords, ", ")))
+			PrintKeywordLocations(keywordMatches[method], keywords)
 		}
-		fmt.Println()
+		fmt.Fprintln(console)
 	} else {
-		fmt.Println("\033[31mX Keywords not found in any .so files.\033[0m")
-		fmt.Println()
+		fmt.Fprintln(status, red("X No keywords about %s found in Java boolean methods.", category))
+		fmt.Fprintln(status)
 	}
-
-	return nil
 }
 
 func main() {
-	apkFile := flag.String("a", "", "Path to the APK file to decode and analyze (required)")
-	flag.StringVar(apkFile, "apk", "", "Path to the APK file to decode and analyze (required)")
+	var apkFiles stringsFlag
+	flag.Var(&apkFiles, "a", "Path to the APK, App Bundle (.aab) or DEX file, a directory or a glob of them, an http(s) URL, or - for stdin; repeat for split APKs (required)")
+	flag.Var(&apkFiles, "apk", "Path to the APK, App Bundle (.aab) or DEX file, a directory or a glob of them, an http(s) URL, or - for stdin; repeat for split APKs (required)")
+	var decodedDirs stringsFlag
+	flag.Var(&decodedDirs, "decoded-dir", "Path to an app already decoded with apktool, scanned as is without apktool; repeat to scan several")
+	followSymlinksFlag := flag.Bool("follow-symlinks", false, "Walk into symbolic links to directories in the decoded app, walking each directory once so links cannot repeat or loop")
 	outputFile := flag.String("o", "", "Path to the output file for boolean method names (required)")
 	flag.StringVar(outputFile, "output", "", "Path to the output file for boolean method names (required)")
+	descriptorFormat := flag.Bool("descriptor-format", false, "Report methods as JVM descriptors (Lcom/app/Class;->method()Z) instead of dotted names")
+	matchArgs := flag.Bool("match-args", false, "Also report boolean methods taking arguments or returning java.lang.Boolean, with their full signature")
+	returnTypesFlag := flag.String("return-types", "Z", "Comma-separated return type descriptors of the methods to scan, e.g. Z,V,I")
+	includePackage := flag.String("include-package", "", "Comma-separated package globs, e.g. com/app or com.app.*; only classes in matching packages are scanned")
+	excludePackage := flag.String("exclude-package", "", "Comma-separated package globs, e.g. com/google,androidx; classes in matching packages are not scanned")
+	caseSensitive := flag.Bool("case-sensitive", false, "Match keywords case-sensitively")
+	wordBoundary := flag.Bool("word-boundary", false, "Only match keywords delimited by non-identifier characters (su no longer matches subscribe), in smali and .so files alike")
+	literalsOnly := flag.Bool("literals-only", false, "Only match keywords inside const-string literals instead of whole smali lines")
+	reassembleStrings := flag.Bool("reassemble-strings", false, "Also match keywords against strings split across const-string literals or built char by char")
+	minScore := flag.Int("min-score", 0, "Only report boolean methods whose keyword and detection weights add up to at least this score")
+	ignoreFile := flag.String("ignore", "", "Path to a file of method names or glob patterns, one per line, to leave out of the results")
+	includeMethods := flag.String("include-methods", "matched", "Which boolean methods to write to the output file: all, matched or none")
+	unmatchedOut := flag.String("unmatched-out", "", "Path to write the boolean methods without any keyword or detection to, one per line")
 	searchSo := flag.Bool("so", false, "Enable searching in .so files")
-	versionFlag := flag.Bool("version", false, "Display the current version of boolseeker")
-	helpFlag := flag.Bool("h", false, "Display help information")
-	flag.BoolVar(helpFlag, "help", false, "Display help information")
+	soKeywords := flag.String("so-keywords", "", "Comma-separated keywords to search for in .so files instead of the built-in categories")
+	noSoDefaultKeywords := flag.Bool("no-so-default-keywords", false, "Require --so-keywords instead of falling back to the built-in categories for .so files")
+	abiList := flag.String("abi", "", "Comma-separated ABIs whose .so files to search, e.g. arm64-v8a (default all)")
+	allABIs := flag.Bool("all-abis", false, "Search every copy of a library shipped for several ABIs instead of only one")
+	groupABIs := flag.Bool("group-abis", false, "Report the copies of a library shipped for several ABIs as one entry, listing the ABIs that matched")
+	maxFileSize := flag.Int("max-filesize", 200, "Skip .so and asset files larger than this many MB, e.g. bundled ML models; 0 disables the limit")
+	useStrings := flag.Bool("strings", false, "Search .so files in the output of the system strings tool instead of parsing them in process (falls back when it is not in PATH)")
+	traceEarlyInit := flag.Bool("trace-early-init", false, "Trace calls from Application/ContentProvider startup methods and highlight the boolean methods they reach")
+	groupBy := flag.String("group-by", "method", "How to list the findings in the text output: method, or class to aggregate methods and keywords per class")
+	findCallers := flag.Bool("callers", false, "List the methods that invoke each boolean method with keywords or detections, to tell wired-in checks from dead code")
+	withContext := flag.Int("with-context", 0, "Write this many lines of smali around each keyword hit below each method in the output file, like grep -C")
+	scanClassMetadata := flag.Bool("scan-class-metadata", false, "Also match keywords against field declarations and class-level annotations, reported per class")
+	scanResources := flag.Bool("scan-resources", false, "Scan decoded resources: link boolean methods to checksums embedded in them and match keywords against string resources")
+	outDir := flag.String("out-dir", "", "Directory to decode APKs into and keep afterwards (default: a temporary directory that is removed)")
+	cacheDir := flag.String("cache-dir", "", "Directory to keep decoded APKs in, keyed by their SHA-256, so scanning the same APK again skips decoding")
+	noCache := flag.Bool("no-cache", false, "Decode APKs even when --cache-dir is set, e.g. in the config file")
+	keep := flag.Bool("keep", false, "Keep the decoded APK after the scan instead of removing it")
+	scanManifest := flag.Bool("scan-manifest", false, "Search AndroidManifest.xml for keywords and integrity signals such as REQUEST_INSTALL_PACKAGES")
+	scanAssets := flag.Bool("scan-assets", false, "Search the files under assets/ for keywords and integrity signals")
+	incremental := flag.String("incremental", "", "Path to a snapshot file; only smali files changed since the snapshot are re-scanned")
+	diffBaseline := flag.String("diff", "", "Path to a baseline APK to scan as well; reports the boolean method findings added, removed or changed since it")
+	onFinding := flag.String("on-finding", "", "Shell command to run when matches are found; the findings are piped to its stdin as JSON")
+	onFindingTimeout := flag.Duration("on-finding-timeout", 30*time.Second, "Maximum time the --on-finding command may run")
+	webhook := flag.String("webhook", "", "URL to POST the findings to as JSON when the scan completes")
+	webhookSecret := flag.String("webhook-secret", "", "Secret used to sign webhook bodies (X-Boolseeker-Signature); defaults to $BOOLSEEKER_WEBHOOK_SECRET")
+	webhookTimeout := flag.Duration("webhook-timeout", 10*time.Second, "Timeout for each webhook delivery attempt")
+	webhookRetries := flag.Int("webhook-retries", 3, "Number of times to retry a failed webhook delivery")
+	aggregateOut := flag.String("aggregate-out", "", "Path to write a JSON summary across all scanned apps to: how many apps hit each category, keyword and method")
+	dbPath := flag.String("db", "", "Path to a SQLite database to add the app, its matched methods and keyword hits to, created if needed (requires sqlite3 in PATH)")
+	emitSummaryStderr := flag.Bool("emit-summary-stderr", false, "Print a single BOOLSEEKER_SUMMARY key=value line with the per-category counts to stderr")
+	flag.BoolVar(emitSummaryStderr, "summary-line", false, "Print a single BOOLSEEKER_SUMMARY key=value line with the per-category counts to stderr")
+	format := flag.String("format", "text", "Output format for the results on stdout: text, json, ndjson, sarif, html, csv or md")
+	engine := flag.String("engine", "apktool", "How to decode the APK: apktool, or dex to parse classes*.dex directly without apktool, writing every class and method, not only those returning boolean, as stub smali")
+	apktoolPath := flag.String("apktool-path", "apktool", "apktool executable to decode APKs with, looked up in PATH unless it is a path")
+	apktoolArgs := flag.String("apktool-args", "", "Extra space-separated arguments for apktool d, e.g. \"--no-res -f\"")
+	smaliOnly := flag.Bool("smali-only", false, "Skip decoding resources (apktool --no-res) for a faster decode; cannot be combined with --scan-manifest or --scan-resources")
+	timeout := flag.Duration("timeout", 0, "Maximum time for the whole run, e.g. 10m; apktool and the scan are stopped when it expires (default no limit)")
+	workers := flag.Int("workers", 0, "Number of smali and .so files to scan in parallel (default: number of CPUs)")
+	verdictThreshold := flag.String("verdict-threshold", strconv.Itoa(scanner.DefaultVerdictThreshold), "Matched methods from which a category counts as present in the verdict, e.g. \"3,root=5\"")
+	failOn := flag.String("fail-on", "", "Comma-separated category IDs (or any) whose findings make boolseeker exit with code 2 (Java) or 3 (.so)")
+	categoriesFlag := flag.String("categories", "", "Comma-separated IDs of the keyword categories and structural detectors to run, e.g. root,frida (default all)")
+	keywordsFile := flag.String("keywords", "", "Path to a JSON or YAML file mapping category names to keyword lists")
+	rulesFile := flag.String("rules", "", "Path to a YAML file of rules combining conditions over method content, run in addition to the built-in ones")
+	keywordsAdd := flag.String("keywords-add", "", "Path to a JSON or YAML file mapping category names to keywords to add to the built-in ones")
+	var keywordFlags stringsFlag
+	flag.Var(&keywordFlags, "keyword", "Keyword to search for in addition to the active ones; repeat for several")
+	keywordCategoryFlag := flag.String("keyword-category", "", "Category to add the --keyword keywords to, a built-in one such as root or a new one (default Custom Keywords)")
+	jsonOut := flag.String("json-out", "", "Path to write the JSON report to, in addition to the text output")
+	htmlOut := flag.String("html-out", "", "Path to write a self-contained HTML report to, in addition to the text output")
+	jsonPretty := flag.Bool("json-pretty", false, "Indent JSON output for readability instead of writing it compactly")
+	validateKeywords := flag.Bool("validate-keywords", false, "Report duplicate keywords within and across categories and exit")
+	configFile := flag.String("config", "", "Path to a YAML file with default flag values (default ./"+defaultConfigFile+" when present)")
+	noColor := flag.Bool("no-color", false, "Disable colored output (also disabled when stdout is not a terminal or NO_COLOR is set)")
+	quietFlag := flag.Bool("quiet", false, "Only print findings, warnings and errors: no spinners, progress counters, decode messages or categories without matches")
+	silent := flag.Bool("silent", false, "Print nothing but errors, relying on the exit code and the output files")
+	verbose := flag.Bool("verbose", false, "Print additional diagnostic output and debug logs")
+	flag.BoolVar(verbose, "v", false, "Print additional diagnostic output and debug logs")
+	logLevel := flag.String("log-level", "warn", "Level of the diagnostic logs written to stderr: debug, info, warn or error")
+	printSchema := flag.Bool("print-schema", false, "Print the JSON Schema of the JSON report, or of an ndjson line with --format ndjson, and exit")
+	selfTest := flag.Bool("self-test", false, "Scan bundled sample smali, check that the expected categories are found and that apktool is installed, and exit")
+	versionFlag := flag.Bool("version", false, "Display the version of boolseeker, the Go version and commit it was built from, and the apktool version")
+	flag.Bool("h", false, "Display help information")
+	flag.Bool("help", false, "Display help information")
 
 	flag.Usage = CustomUsage
+	// Help is handled before parsing, so it works alongside other flags,
+	// even invalid ones, and never depends on the config file.
+	if helpRequested(os.Args[1:]) {
+		flag.Usage()
+		return
+	}
 	flag.Parse()
 
+	configPath := *configFile
+	if configPath == "" {
+		configPath = defaultConfigFile
+	}
+	configErr := ApplyConfigFile(configPath, *configFile != "")
+
+	followSymlinks = *followSymlinksFlag
+	colorEnabled = !*noColor && os.Getenv("NO_COLOR") == "" && isatty.IsTerminal(os.Stdout.Fd()) && enableVirtualTerminal()
+	// The spinner frames are colored by colorize, not by the spinner.
+	color.NoColor = true
+	quiet = *quietFlag || *silent
+
+	if err := SetupLogging(*logLevel, *verbose); err != nil {
+		fmt.Fprintln(os.Stderr, red("✖️ Error: %v.", err))
+		os.Exit(1)
+	}
+
+	if configErr != nil {
+		fmt.Fprintln(os.Stderr, red("✖️ %v (command-line flags override the config file, which overrides the built-in defaults)", configErr))
+		os.Exit(1)
+	}
+
 	if *versionFlag {
-		fmt.Printf("Boolseeker version %s\n", version)
+		PrintVersion(os.Stdout, *apktoolPath)
 		return
 	}
 
-	if *helpFlag {
-		flag.Usage()
+	if *printSchema {
+		if err := PrintSchema(os.Stdout, *format); err != nil {
+			fmt.Fprintln(os.Stderr, red("✖️ %v", err))
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *selfTest {
+		if !RunSelfTest(context.Background(), *apktoolPath) {
+			os.Exit(1)
+		}
 		return
 	}
 
-	if *apkFile == "" || *outputFile == "" {
-		fmt.Println("\033[31m✖️ Error: -a/--apk and -o/--output flags are required.\033[0m")
+	if *keywordsFile != "" {
+		categories, err := LoadKeywordFile(*keywordsFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, red("✖️ %v", err))
+			os.Exit(1)
+		}
+		keywordCategories = categories
+	}
+
+	if *keywordsAdd != "" {
+		categories, err := LoadKeywordFile(*keywordsAdd)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, red("✖️ %v", err))
+			os.Exit(1)
+		}
+		keywordCategories = MergeKeywordCategories(keywordCategories, categories)
+	}
+
+	if *keywordCategoryFlag != "" && len(keywordFlags) == 0 {
+		fmt.Fprintln(os.Stderr, red("✖️ Error: --keyword-category names the category of --keyword keywords; it requires --keyword."))
 		flag.Usage()
 		os.Exit(1)
 	}
+	if len(keywordFlags) > 0 {
+		keywordCategories = MergeKeywordCategories(keywordCategories, []KeywordCategory{CommandLineKeywords(*keywordCategoryFlag, keywordFlags)})
+	}
 
-	decodedDirectory := strings.TrimSuffix(filepath.Base(*apkFile), ".apk")
-	if _, err := os.Stat(decodedDirectory); err == nil {
-		CleanUp(decodedDirectory)
+	if *rulesFile != "" {
+		detectors, err := LoadRuleFile(*rulesFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, red("✖️ %v", err))
+			os.Exit(1)
+		}
+		structuralDetectors = append(structuralDetectors, detectors...)
 	}
 
-	err := CheckApkTool()
-	if err != nil {
-		fmt.Println(err)
+	if *categoriesFlag != "" {
+		categories, detectors, err := scanner.SelectCategories(keywordCategories, structuralDetectors, *categoriesFlag)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, red("✖️ Error: invalid --categories value: %v.", err))
+			flag.Usage()
+			os.Exit(1)
+		}
+		keywordCategories, structuralDetectors = categories, detectors
+	}
+
+	var dedupedKeywords []scanner.KeywordDuplicate
+	keywordCategories, dedupedKeywords = scanner.DedupeKeywordCategories(keywordCategories)
+	searchKeywords = scanner.CategoryKeywordUnion(keywordCategories)
+
+	if _, err := scanner.CompileKeywordPatterns(append(slices.Clone(searchKeywords), strings.Split(*soKeywords, ",")...), *caseSensitive); err != nil {
+		fmt.Fprintln(os.Stderr, red("✖️ Error: %v.", err))
 		os.Exit(1)
 	}
 
-	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
-	s.Color("red", "yellow", "blue", "green")
-	s.Start()
+	if *validateKeywords {
+		if !PrintKeywordValidation(keywordCategories) {
+			os.Exit(1)
+		}
+		return
+	}
 
-	err = DecodeAPK(*apkFile, decodedDirectory, s)
+	if *verbose {
+		for _, duplicate := range dedupedKeywords {
+			fmt.Fprintln(console, yellow("! Removed duplicate keyword %q from %s", duplicate.Keyword, duplicate.Categories[0]))
+		}
+	}
+
+	var ignorePatterns []*regexp.Regexp
+	if *ignoreFile != "" {
+		var err error
+		if ignorePatterns, err = LoadIgnoreFile(*ignoreFile); err != nil {
+			fmt.Fprintln(os.Stderr, red("✖️ %v", err))
+			os.Exit(1)
+		}
+	}
+
+	var failOnCategories map[string]bool
+	if *failOn != "" {
+		var err error
+		if failOnCategories, err = ParseFailOn(*failOn); err != nil {
+			fmt.Fprintln(os.Stderr, red("✖️ Error: %v.", err))
+			flag.Usage()
+			os.Exit(1)
+		}
+	}
+
+	verdictThresholds, err := scanner.ParseVerdictThresholds(*verdictThreshold, keywordCategories)
 	if err != nil {
-		s.Stop()
-		fmt.Println(err)
+		fmt.Fprintln(os.Stderr, red("✖️ Error: %v.", err))
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	if *searchSo && *noSoDefaultKeywords && *soKeywords == "" {
+		fmt.Fprintln(os.Stderr, red("✖️ Error: --no-so-default-keywords requires --so-keywords."))
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	if !slices.Contains([]string{"text", "json", "ndjson", "sarif", "html", "csv", "md"}, *format) {
+		fmt.Fprintln(os.Stderr, red("✖️ Error: invalid --format value %q (expected text, json, ndjson, sarif, html, csv or md).", *format))
+		flag.Usage()
+		os.Exit(1)
+	}
+	if *format != "text" || *silent {
+		console = io.Discard
+	}
+	if *format != "text" || *quietFlag || *silent {
+		status = io.Discard
+	}
+
+	if *engine != "apktool" && *engine != "dex" {
+		fmt.Fprintln(os.Stderr, red("✖️ Error: invalid --engine value %q (expected apktool or dex).", *engine))
+		flag.Usage()
 		os.Exit(1)
 	}
-	s.Stop()
-	fmt.Printf("\033[32m✔ Successfully decompiled %s to %s\033[0m\n", *apkFile, decodedDirectory)
 
-	s.Start()
-	s.Suffix = fmt.Sprintf(" Searching for Java boolean methods and keywords in %s...", decodedDirectory)
-	var booleanMethods []string
-	booleanMethodsWithKeywords := make(map[string][]string)
-	smaliDirs, err := filepath.Glob(filepath.Join(decodedDirectory, "smali*"))
+	if *groupBy != "method" && *groupBy != "class" {
+		fmt.Fprintln(os.Stderr, red("✖️ Error: invalid --group-by value %q (expected method or class).", *groupBy))
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	if *includeMethods != "all" && *includeMethods != "matched" && *includeMethods != "none" {
+		fmt.Fprintln(os.Stderr, red("✖️ Error: invalid --include-methods value %q (expected all, matched or none).", *includeMethods))
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	if *withContext < 0 {
+		fmt.Fprintln(os.Stderr, red("✖️ Error: invalid --with-context value %d (expected a number of lines).", *withContext))
+		flag.Usage()
+		os.Exit(1)
+	}
+	if *maxFileSize < 0 {
+		fmt.Fprintln(os.Stderr, red("✖️ Error: invalid --max-filesize value %d (expected a size in MB, or 0 for no limit).", *maxFileSize))
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	returnTypes, err := scanner.ParseReturnTypes(*returnTypesFlag)
+	if err == nil && len(returnTypes) == 0 {
+		err = errors.New("no return types given")
+	}
 	if err != nil {
-		s.Stop()
-		fmt.Println(err)
+		fmt.Fprintln(os.Stderr, red("✖️ Error: invalid --return-types value: %v.", err))
+		flag.Usage()
 		os.Exit(1)
 	}
 
-	for _, smaliDir := range smaliDirs {
-		methods, keywordsMap, err := FindBooleanMethodsInSmali(smaliDir)
-		if err != nil {
-			s.Stop()
-			fmt.Println(err)
-			os.Exit(1)
+	var stringsTool string
+	if *useStrings {
+		if stringsTool, err = exec.LookPath("strings"); err != nil {
+			fmt.Fprintln(os.Stderr, yellow("! strings is not installed or not found in PATH; searching .so files in process"))
 		}
-		booleanMethods = append(booleanMethods, methods...)
-		for k, v := range keywordsMap {
-			booleanMethodsWithKeywords[k] = v
+	}
+
+	var abis []string
+	for _, abi := range strings.Split(*abiList, ",") {
+		if abi = strings.TrimSpace(abi); abi != "" {
+			abis = append(abis, abi)
 		}
 	}
 
-	s.Stop()
+	var packages scanner.PackageFilter
+	if packages.Include, err = scanner.ParsePackagePatterns(*includePackage); err != nil {
+		fmt.Fprintln(os.Stderr, red("✖️ Error: invalid --include-package value: %v.", err))
+		flag.Usage()
+		os.Exit(1)
+	}
+	if packages.Exclude, err = scanner.ParsePackagePatterns(*excludePackage); err != nil {
+		fmt.Fprintln(os.Stderr, red("✖️ Error: invalid --exclude-package value: %v.", err))
+		flag.Usage()
+		os.Exit(1)
+	}
 
-	methodSet := make(map[string]struct{})
-	for _, method := range booleanMethods {
-		methodSet[method] = struct{}{}
+	if (len(apkFiles) == 0 && len(decodedDirs) == 0) || (*outputFile == "" && *includeMethods != "none") {
+		fmt.Fprintln(os.Stderr, red("✖️ Error: -a/--apk (or --decoded-dir) and -o/--output flags are required."))
+		flag.Usage()
+		os.Exit(1)
 	}
 
-	output, err := os.Create(*outputFile)
-	if err != nil {
-		fmt.Println(err)
+	var inputs []AppInput
+	if len(apkFiles) > 0 {
+		if inputs, err = GroupInputs(apkFiles); err != nil {
+			fmt.Fprintln(os.Stderr, red("✖️ %v", err))
+			os.Exit(1)
+		}
+	}
+	for _, dir := range decodedDirs {
+		if smaliDirs, err := scanner.FindSmaliDirs(dir, followSymlinks); err != nil || len(smaliDirs) == 0 {
+			hint := ""
+			if !followSymlinks {
+				if linked, err := scanner.FindSmaliDirs(dir, true); err == nil && len(linked) > 0 {
+					hint = " Its smali directories are symbolic links; add --follow-symlinks to scan them."
+				}
+			}
+			fmt.Fprintln(os.Stderr, red("✖️ Error: %s is not a directory decoded by apktool: it has no smali directories.%s", dir, hint))
+			os.Exit(1)
+		}
+		inputs = append(inputs, AppInput{Base: dir, Decoded: true})
+	}
+	if *format == "html" && len(inputs) > 1 {
+		fmt.Fprintln(os.Stderr, red("✖️ Error: --format html writes a single page; use --html-out to write one page per app when scanning several."))
 		os.Exit(1)
 	}
-	defer output.Close()
 
-	for method := range methodSet {
-		_, err := output.WriteString(method + "\n")
+	apktool := Apktool{Path: *apktoolPath}
+	if apktool.Args, err = ParseApktoolArgs(*apktoolArgs); err != nil {
+		fmt.Fprintln(os.Stderr, red("✖️ Error: invalid --apktool-args value: %v.", err))
+		flag.Usage()
+		os.Exit(1)
+	}
+	if *smaliOnly {
+		if *scanManifest || *scanResources {
+			fmt.Fprintln(os.Stderr, red("✖️ Error: --smali-only leaves AndroidManifest.xml and the resources compiled; it cannot be combined with --scan-manifest or --scan-resources."))
+			flag.Usage()
+			os.Exit(1)
+		}
+		if !slices.Contains(apktool.Args, "--no-res") && !slices.Contains(apktool.Args, "-r") {
+			apktool.Args = append(apktool.Args, "--no-res")
+		}
+	}
+	// Decoded directories and DEX files are scanned without apktool.
+	needsApktool := slices.ContainsFunc(inputs, func(input AppInput) bool { return !input.Decoded && !scanner.IsRawDex(input.Base) })
+	if *engine == "apktool" && (needsApktool || *diffBaseline != "") {
+		apktool.Path, err = CheckApkTool(*apktoolPath)
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, red("%v", err))
+			os.Exit(1)
+		}
+	}
+
+	var sqlitePath string
+	if *dbPath != "" {
+		if sqlitePath, err = CheckSqlite(); err != nil {
+			fmt.Fprintln(os.Stderr, red("%v", err))
+			os.Exit(1)
+		}
+	}
+
+	var decodeCache *DecodeCache
+	if *cacheDir != "" && !*noCache {
+		if *keep || *outDir != "" {
+			fmt.Fprintln(os.Stderr, red("✖️ Error: --cache-dir keeps decoded APKs itself; it cannot be combined with --keep or --out-dir."))
+			flag.Usage()
+			os.Exit(1)
+		}
+		if decodeCache, err = NewDecodeCache(*cacheDir, *engine, apktool.Args); err != nil {
+			fmt.Fprintln(os.Stderr, red("✖️ %v", err))
 			os.Exit(1)
 		}
 	}
 
-	root_detection_keywords := []string{"com.noshufou.android.su", "com.noshufou.android.su.elite", "eu.chainfire.supersu", "com.koushikdutta.superuser", "com.thirdparty.superuser", "com.yellowes.su", "com.koushikdutta.rommanager", "com.koushikdutta.rommanager.license", "com.dimonvideo.luckypatcher", "com.chelpus.lackypatch", "com.ramdroid.appquarantine", "com.ramdroid.appquarantinepro", "com.devadvance.rootcloak", "com.devadvance.rootcloakplus", "de.robv.android.xposed.installer", "com.saurik.substrate", "com.zachspong.temprootremovejb", "com.amphoras.hidemyroot", "com.amphoras.hidemyrootadfree", "com.formyhm.hiderootPremium", "com.formyhm.hideroot", "me.phh.superuser", "eu.chainfire.supersu.pro", "com.kingouser.com", "com.android.vending.billing.InAppBillingService.COIN", "com.topjohnwu.magisk", "su", "busybox", "supersu", "Superuser.apk", "KingoUser.apk", "SuperSu.apk", "magisk", "ro.build.selinux", "ro.debuggable", "service.adb.root", "ro.secure", "root", "test-keys", "superuser", "Superuser", "daemonsu", "99SuperSUDaemon", ".has_su_daemon", "/system/app/Superuser.apk", "/system/xbin/su", "/system/usr/we-need-root", "/data/local/bin/su", "/data/local/su", "/data/local/xbin/su", "/dev/com.koushikdutta.superuser.daemon/", "/sbin/su", "/system/bin/failsafe/su", "/system/bin/su", "/su/bin/su", "/system/sd/xbin/su", "/system/xbin/busybox", "/system/xbin/daemonsu", "/system/xbin/su", "/system/sbin/su", "/vendor/bin/su", "/cache/su", "/data/su", "/dev/su", "/system/bin/.ext/su", "/system/usr/we-need-root/su", "/system/app/Kinguser.apk", "/data/adb/magisk", "/sbin/.magisk", "/cache/.disable_magisk", "/dev/.magisk.unblock", "/cache/magisk.log", "/data/adb/magisk.img", "/data/adb/magisk.db", "/data/adb/magisk_simple", "/init.magisk.rc", "/system/xbin/ku.sud", "/data/adb/ksu", "/data/adb/ksud", "me.weishu.kernelsu"}
-	emulator_detection_keywords := []string{"init.svc.qemud", "init.svc.qemu-props", "qemu.hw.mainkeys", "qemu.sf.fake_camera", "qemu.sf.lcd_density", "ro.bootloader", "ro.bootmode", "ro.hardware", "ro.kernel.android.qemud", "ro.kernel.qemu.gles", "ro.kernel.qemu", "ro.product.device", "ro.product.model", "ro.product.name", "ro.serialno", "ueventd.android_x86.rc", "x86.prop", "ueventd.ttVM_x86.rc", "init.ttVM_x86.rc", "fstab.ttVM_x86", "fstab.vbox86", "init.vbox86.rc", "ueventd.vbox86.rc", "/dev/socket/qemud", "/dev/qemu_pipe", "/system/lib/libc_malloc_debug_qemu.so", "/sys/qemu_trace", "/system/bin/qemu-props", "/dev/socket/genyd", "/dev/socket/baseband_genyd", "/proc/tty/drivers", "/proc/cpuinfo", "genymotion", "geny", "emulator", "nox", "/dev/qemu_trace", "/system/bin/netcfg"}
-	runtime_integrity_verification_keywords := []string{"27042", "frida", "27043", "FridaGadget", "xposed"}
-	file_integrity_keywords := []string{"MessageDigest", "getPackageInfo", "signature"}
-	fmt.Printf("\033[32m✔ Total number of unique boolean methods found: %d\033[0m\n", len(methodSet))
-	fmt.Printf("\033[32m✔ Unique boolean methods written in %s\033[0m\n", *outputFile)
+	// ctx is cancelled on Ctrl-C, SIGTERM or when --timeout expires; a second
+	// Ctrl-C exits right away.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	// cleanups remove the temporary files and partially decoded directories
+	// of the current scan when boolseeker exits early.
+	var cleanups []func()
+	exit := func(code int) {
+		for i := len(cleanups) - 1; i >= 0; i-- {
+			cleanups[i]()
+		}
+		os.Exit(code)
+	}
+	// exitIfCancelled reports a timeout or interrupt and exits; it is checked
+	// whenever an operation that honours ctx fails.
+	exitIfCancelled := func(progress *Progress) {
+		if ctx.Err() == nil {
+			return
+		}
+		progress.Stop()
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			fmt.Fprintln(os.Stderr, red("✖️ Timed out after %s", *timeout))
+			exit(1)
+		}
+		fmt.Fprintln(os.Stderr, red("✖️ Interrupted"))
+		exit(130)
+	}
+
+	decode := func(apkFile, decodedDirectory string, progress *Progress) error {
+		if scanner.IsRawDex(apkFile) {
+			progress.Status("Parsing DEX file: %s...", apkFile)
+			if err := scanner.DecodeRawDex(apkFile, decodedDirectory); err != nil {
+				return fmt.Errorf("✖ %w", err)
+			}
+			return nil
+		}
+		if *engine == "dex" {
+			progress.Status("Parsing DEX files: %s...", apkFile)
+			return DecodeDex(apkFile, decodedDirectory)
+		}
+		return DecodeAPK(ctx, apktool, apkFile, decodedDirectory, progress)
+	}
+
+	var stdoutReports []*Report
+	// analyze scans one app and returns its report and exit code. With
+	// baseline, the findings are compared against it; with scanOnly, as for
+	// the --diff baseline, nothing is written or delivered.
+	analyze := func(input AppInput, outputFile, unmatchedOut, jsonOut, htmlOut, incremental, decodedDirectory string, baseline *Report, scanOnly bool) (*Report, int) {
+		apkFile := input.Base
+		progress := newProgress()
+		progress.Start()
+
+		// localFile is the APK on disk; it stays empty for a decoded
+		// directory, which is scanned as is.
+		var localFile, archiveEntry, cacheEntry string
+		cached := false
+		decodeStart := time.Now()
+		if input.Decoded {
+			decodedDirectory = apkFile
+		} else {
+			if apkFile == "-" {
+				progress.Status("Reading APK from stdin...")
+			} else if isRemoteInput(apkFile) {
+				progress.Status("Downloading APK: %s...", apkFile)
+			}
+			stagedFile, removeStaged, err := StageInput(ctx, apkFile, *timeout)
+			if err != nil {
+				exitIfCancelled(progress)
+				progress.Stop()
+				fmt.Fprintln(os.Stderr, red("%v", err))
+				exit(1)
+			}
+			defer removeStaged()
+			cleanups = append(cleanups, removeStaged)
+			localFile = stagedFile
+
+			if isArchiveInput(inputName(apkFile)) {
+				progress.Status("Extracting APK from %s...", apkFile)
+				extracted, removeExtracted, err := ExtractAPK(localFile, inputName(apkFile))
+				if err != nil {
+					progress.Stop()
+					fmt.Fprintln(os.Stderr, red("%v", err))
+					exit(1)
+				}
+				defer removeExtracted()
+				cleanups = append(cleanups, removeExtracted)
+				localFile = extracted
+				archiveEntry = filepath.Base(extracted)
+			}
+
+			// With --cache-dir, a cached decode of the same APK is reused; a new
+			// one is decoded into a staging directory and then moved into place.
+			if decodeCache != nil {
+				if cacheEntry, err = decodeCache.Entry(localFile, input.Splits); err != nil {
+					progress.Stop()
+					fmt.Fprintln(os.Stderr, red("✖️ %v", err))
+					exit(1)
+				}
+				cached = decodeCache.Has(cacheEntry)
+				if !cached {
+					staging, err := decodeCache.Staging()
+					if err != nil {
+						progress.Stop()
+						fmt.Fprintln(os.Stderr, red("✖️ Could not create a staging directory in %s: %v", decodeCache.Directory, err))
+						exit(1)
+					}
+					defer os.RemoveAll(staging)
+					cleanups = append(cleanups, func() { os.RemoveAll(staging) })
+					decodedDirectory = filepath.Join(staging, filepath.Base(decodedDirectory))
+				}
+			}
 
-	if len(booleanMethodsWithKeywords) > 0 {
-		foundKeywords := false
-		methodsWithKeywords := make(map[string][]string)
-		for method, keywords := range booleanMethodsWithKeywords {
-			var filteredKeywords []string
-			for _, keyword := range keywords {
-				for _, runtimeKeyword := range root_detection_keywords {
-					if keyword == runtimeKeyword {
-						filteredKeywords = append(filteredKeywords, keyword)
+			if !cached {
+				err = decode(localFile, decodedDirectory, progress)
+				for _, split := range input.Splits {
+					if err != nil {
+						break
+					}
+					splitDirectory := decodedDirectory + "_" + splitName(split)
+					cleanups = append(cleanups, func() { CleanUp(splitDirectory) })
+					if err = decode(split, splitDirectory, progress); err == nil {
+						err = MergeSplit(splitDirectory, decodedDirectory, splitName(split))
 					}
 				}
+				if err == nil && decodeCache != nil {
+					err = decodeCache.Store(decodedDirectory, cacheEntry)
+				}
+				if err != nil {
+					exitIfCancelled(progress)
+					progress.Stop()
+					fmt.Fprintln(os.Stderr, red("%v", err))
+					exit(1)
+				}
 			}
-			if len(filteredKeywords) > 0 {
-				foundKeywords = true
-				methodsWithKeywords[method] = filteredKeywords
+			if decodeCache != nil {
+				decodedDirectory = cacheEntry
 			}
 		}
+		progress.Stop()
+		decodeDuration := time.Since(decodeStart)
+		if archiveEntry != "" {
+			fmt.Fprintln(status, green("✔ Extracted %s from %s", archiveEntry, apkFile))
+		}
+		switch {
+		case input.Decoded:
+			fmt.Fprintln(status, green("✔ Scanning decoded directory %s", decodedDirectory))
+		case cached:
+			slog.Info("reused cached decode", "apk", apkFile, "engine", *engine, "directory", decodedDirectory)
+			fmt.Fprintln(status, green("✔ Reusing decoded %s from cache: %s", apkFile, decodedDirectory))
+		default:
+			slog.Info("decoded APK", "apk", apkFile, "engine", *engine, "directory", decodedDirectory, "duration", decodeDuration)
+			fmt.Fprintln(status, green("✔ Successfully decompiled %s to %s", apkFile, decodedDirectory))
+		}
+		if len(input.Splits) > 0 {
+			fmt.Fprintln(status, green("✔ Merged %d split APKs: %s", len(input.Splits), strings.Join(input.Splits, ", ")))
+		}
+		appInfo := ReadAppInfo(decodedDirectory)
+		if appInfo != (AppInfo{}) {
+			fmt.Fprintln(status, green("✔ App: %s", appInfo))
+		}
+
+		scanOptions := scanner.Options{
+			Categories:       keywordCategories,
+			Detectors:        structuralDetectors,
+			DescriptorFormat: *descriptorFormat,
+			MatchArgs:        *matchArgs,
+			ReturnTypes:      returnTypes,
+			Packages:         packages,
+			Workers:          *workers,
+			CaseSensitive:    *caseSensitive,
+			WordBoundary:     *wordBoundary,
+			LiteralsOnly:     *literalsOnly,
+			Reassemble:       *reassembleStrings,
+			StringsTool:      stringsTool,
+			ClassMetadata:    *scanClassMetadata,
+			ContextLines:     *withContext,
+			ABIs:             abis,
+			AllABIs:          *allABIs,
+			FollowSymlinks:   followSymlinks,
+			GroupABIs:        *groupABIs,
+			MaxFileSize:      int64(*maxFileSize) << 20,
+		}
+		if *format == "ndjson" && !scanOnly {
+			scanOptions.OnFinding = NewFindingStream(os.Stdout, apkFile, *minScore, ignorePatterns).Write
+		}
+		scan, err := scanner.New(scanOptions)
+		if err == nil && *scanResources {
+			scan, err = scan.WithResourceChecksums(decodedDirectory)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, red("✖️ %v", err))
+			exit(1)
+		}
+		scanConfig := EffectiveScanConfig(*searchSo, *engine, scan.Options())
 
-		if foundKeywords {
-			fmt.Println()
-			fmt.Println("\033[33m✔ Java boolean methods containing keywords about Rooted Device Detection:\033[0m")
-			for method, keywords := range methodsWithKeywords {
-				fmt.Printf("  \033[36m+ Java method: %s \033[0m- \033[31mKeywords found: %s\033[0m\n", method, strings.Join(keywords, ", "))
+		var cache *SmaliCache
+		// A nil *SmaliCache must not become a non-nil scanner.Cache.
+		var fileCache scanner.Cache
+		if incremental != "" {
+			cache, err = LoadSmaliCache(incremental, scanConfig)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, red("✖️ %v", err))
+				exit(1)
 			}
-			fmt.Println()
-		} else {
-			fmt.Println("\033[31mX No keywords about Rooted Device Detection found in Java boolean methods.\033[0m")
-			fmt.Println()
+			fileCache = cache
 		}
 
-		foundKeywords = false
+		var nativeCategories []KeywordCategory
+		if *searchSo {
+			nativeCategories = keywordCategories
+			if *soKeywords != "" {
+				nativeCategories = soKeywordCategories(strings.Split(*soKeywords, ","))
+			}
+		}
 
-		methodsWithKeywords = make(map[string][]string)
+		progress.Start()
+		scanStart := time.Now()
+		app, err := scan.ScanApp(ctx, decodedDirectory, scanner.AppOptions{
+			APKFile:          localFile,
+			Manifest:         *scanManifest,
+			Assets:           *scanAssets,
+			Resources:        *scanResources,
+			EarlyInit:        *traceEarlyInit,
+			Callers:          *findCallers,
+			Native:           *searchSo,
+			NativeCategories: nativeCategories,
+			MinScore:         *minScore,
+			Ignore:           ignorePatterns,
+			Thresholds:       verdictThresholds,
+			Cache:            fileCache,
+			Progress:         progress,
+		})
+		if err != nil {
+			exitIfCancelled(progress)
+			progress.Stop()
+			fmt.Fprintln(os.Stderr, red("%v", err))
+			exit(1)
+		}
+		progress.Stop()
+		results := app.Smali
 
-		for method, keywords := range booleanMethodsWithKeywords {
-			var filteredKeywords []string
-			for _, keyword := range keywords {
-				for _, runtimeKeyword := range emulator_detection_keywords {
-					if keyword == runtimeKeyword {
-						filteredKeywords = append(filteredKeywords, keyword)
-					}
+		if len(app.Packing) > 0 {
+			fmt.Fprintln(console, yellow("! The app looks packed, so the static scan is likely incomplete:"))
+			for _, sign := range app.Packing {
+				fmt.Fprintln(console, yellow("  - %s", sign))
+			}
+		}
+		if cache != nil {
+			if err := cache.Save(incremental, *jsonPretty); err != nil {
+				fmt.Fprintln(os.Stderr, red("✖️ %v", err))
+				exit(1)
+			}
+			fmt.Fprintln(status, green("✔ Incremental scan: %d smali files reused from %s, %d re-scanned", cache.Reused, incremental, cache.Scanned))
+		}
+		if app.Suppressed > 0 {
+			fmt.Fprintln(console, yellow("! %d methods suppressed by %s", app.Suppressed, *ignoreFile))
+		}
+
+		booleanMethodsWithKeywords := results.Keywords()
+		keywordMatches := results.Matches()
+		scores := results.Scores()
+		detectionsByMethod := results.Detections()
+
+		methodSet := make(map[string]struct{})
+		for _, method := range results.Methods() {
+			methodSet[method] = struct{}{}
+		}
+
+		writtenMethods := 0
+		if *includeMethods != "none" && !scanOnly {
+			methods := make([]string, 0, len(methodSet))
+			for method := range methodSet {
+				if *includeMethods == "matched" && len(booleanMethodsWithKeywords[method]) == 0 && len(detectionsByMethod[method]) == 0 {
+					continue
 				}
+				methods = append(methods, method)
 			}
-			if len(filteredKeywords) > 0 {
-				foundKeywords = true
-				methodsWithKeywords[method] = filteredKeywords
+			write := WriteMethodList
+			if *withContext > 0 {
+				write = func(path string, methods []string) error { return WriteMethodContext(path, methods, keywordMatches) }
 			}
+			if err := write(outputFile, methods); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				exit(1)
+			}
+			writtenMethods = len(methods)
 		}
 
-		if foundKeywords {
-			fmt.Println("\033[33m✔ Java boolean methods containing keywords about Emulator Detection:\033[0m")
-			for method, keywords := range methodsWithKeywords {
-				fmt.Printf("  \033[36m+ Java method: %s \033[0m- \033[31mKeywords found: %s\033[0m\n", method, strings.Join(keywords, ", "))
+		unmatchedMethods := 0
+		if unmatchedOut != "" && !scanOnly {
+			var methods []string
+			for method := range methodSet {
+				if len(booleanMethodsWithKeywords[method]) == 0 && len(detectionsByMethod[method]) == 0 {
+					methods = append(methods, method)
+				}
 			}
-			fmt.Println()
-		} else {
-			fmt.Println("\033[31mX No keywords about Emulator Detection found in Java boolean methods.\033[0m")
-			fmt.Println()
+			if err := WriteMethodList(unmatchedOut, methods); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				exit(1)
+			}
+			unmatchedMethods = len(methods)
+		}
+
+		fmt.Fprintln(status, green("✔ Total number of unique boolean methods found: %d", len(methodSet)))
+		switch {
+		case scanOnly:
+		case *includeMethods == "all":
+			fmt.Fprintln(status, green("✔ All %d unique boolean methods written in %s", writtenMethods, outputFile))
+		case *includeMethods == "matched":
+			fmt.Fprintln(status, green("✔ %d boolean methods with keywords or detections written in %s", writtenMethods, outputFile))
+		}
+		if unmatchedOut != "" && !scanOnly {
+			fmt.Fprintln(status, green("✔ %d boolean methods without keywords or detections written in %s", unmatchedMethods, unmatchedOut))
 		}
 
-		foundKeywords = false
+		framework := detectFramework(decodedDirectory)
+		if framework != "" {
+			fmt.Fprintln(status, green("✔ App framework: %s", framework))
+			warning := fmt.Sprintf("! %s apps keep most of their logic outside smali, so Java method coverage is limited", framework)
+			if !*searchSo {
+				warning += "; consider re-running with -so"
+			}
+			fmt.Fprintln(console, yellow("%s", warning))
+		}
 
-		methodsWithKeywords = make(map[string][]string)
+		summaries := app.Summaries
+		PrintSummaryTable(summaries)
+		fmt.Fprintln(status)
+		PrintVerdict(app.Verdict)
 
-		for method, keywords := range booleanMethodsWithKeywords {
-			var filteredKeywords []string
-			for _, keyword := range keywords {
-				for _, runtimeKeyword := range runtime_integrity_verification_keywords {
-					if keyword == runtimeKeyword {
-						filteredKeywords = append(filteredKeywords, keyword)
-					}
+		if *traceEarlyInit {
+			fmt.Fprintln(console)
+			PrintEarlyInitChecks(app.EarlyInit)
+		}
+		if *findCallers {
+			fmt.Fprintln(console)
+			PrintCallers(app.Callers)
+		}
+
+		var classes []ClassSummary
+		if *groupBy == "class" {
+			classes = GroupFindingsByClass(results.Findings)
+			fmt.Fprintln(console)
+			PrintClassSummaries(classes)
+		} else {
+			if len(booleanMethodsWithKeywords) > 0 {
+				fmt.Fprintln(console)
+				for _, category := range keywordCategories {
+					PrintCategoryMatches(category.Name, category.Keywords, booleanMethodsWithKeywords, keywordMatches, scores)
 				}
+			} else {
+				fmt.Fprintln(status)
+				fmt.Fprintln(status, red("X No keywords found in Java boolean methods."))
+				fmt.Fprintln(status)
 			}
-			if len(filteredKeywords) > 0 {
-				foundKeywords = true
-				methodsWithKeywords[method] = filteredKeywords
+
+			for _, detector := range scan.Options().Detectors {
+				PrintDetections(detector, detectionsByMethod)
 			}
 		}
 
-		if foundKeywords {
-			fmt.Println("\033[33m✔ Java boolean methods containing keywords about Runtime Integrity Verification:\033[0m")
-			for method, keywords := range methodsWithKeywords {
-				fmt.Printf("  \033[36m+ Java method: %s \033[0m- \033[31mKeywords found: %s\033[0m\n", method, strings.Join(keywords, ", "))
+		PrintProtectionSDKs(app.Protection)
+		if *scanClassMetadata {
+			PrintClassMatches(results.ClassMatches)
+		}
+		if app.AppFiles != nil {
+			PrintAppFileMatches(app.AppFiles)
+		}
+		if *scanResources {
+			PrintResourceMatches(app.Resources)
+		}
+
+		if app.Native != nil {
+			if len(app.Native.Keywords) > 0 {
+				for _, category := range nativeCategories {
+					PrintNativeCategoryMatches(category.Name, category.Keywords, app.Native)
+				}
+			} else {
+				fmt.Fprintln(status, red("X Keywords not found in any .so files."))
+				fmt.Fprintln(status)
 			}
-			fmt.Println()
-		} else {
-			fmt.Println("\033[31mX No keywords about Runtime Integrity Verification found in Java boolean methods.\033[0m")
-			fmt.Println()
+
+			PrintNativeIntegrity(app.Native)
 		}
 
-		foundKeywords = false
+		report := NewReport(apkFile, scanConfig, results, summaries)
+		report.Splits = input.Splits
+		report.AppInfo = appInfo
+		report.Framework = framework
+		report.Verdict = app.Verdict
+		report.EarlyInit = app.EarlyInit
+		report.Callers = app.Callers
+		report.Classes = classes
+		report.AppFiles = app.AppFiles
+		report.Resources = app.Resources
+		report.Packing = app.Packing
+		report.Protection = app.Protection
+		report.Skipped = results.Skipped
+		report.Native = app.Native
+
+		stats := ScanStats{
+			SmaliFiles:     app.SmaliFiles,
+			BooleanMethods: len(methodSet),
+			MatchedMethods: len(booleanMethodsWithKeywords),
+			Decode:         decodeDuration,
+			Scan:           time.Since(scanStart),
+		}
+		if report.Native != nil {
+			stats.SoFiles = report.Native.Files
+		}
+		PrintScanStats(stats, summaries, *verbose)
 
-		methodsWithKeywords = make(map[string][]string)
-		for method, keywords := range booleanMethodsWithKeywords {
-			var filteredKeywords []string
-			for _, keyword := range keywords {
-				for _, runtimeKeyword := range file_integrity_keywords {
-					if keyword == runtimeKeyword {
-						filteredKeywords = append(filteredKeywords, keyword)
-					}
+		if localFile != "" {
+			if report.APKSHA256, err = hashFile(localFile); err != nil {
+				fmt.Fprintln(os.Stderr, red("✖️ Could not hash %s: %v", apkFile, err))
+			}
+		}
+		if scanOnly {
+			return report, 0
+		}
+
+		if baseline != nil {
+			report.Diff = DiffReports(baseline, report)
+			PrintDiff(report.Diff)
+		}
+
+		// Reports for stdout are written once every input is scanned, as one
+		// document.
+		if *format != "text" && *format != "ndjson" {
+			stdoutReports = append(stdoutReports, report)
+		}
+		if jsonOut != "" {
+			if err := WriteJSONReport(jsonOut, report, *jsonPretty); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				exit(1)
+			}
+			fmt.Fprintln(status, green("✔ JSON report written in %s", jsonOut))
+		}
+		if htmlOut != "" {
+			if err := WriteHTMLReport(htmlOut, report); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				exit(1)
+			}
+			fmt.Fprintln(status, green("✔ HTML report written in %s", htmlOut))
+		}
+
+		if *dbPath != "" {
+			if err := WriteDatabase(sqlitePath, *dbPath, report, time.Now()); err != nil {
+				fmt.Fprintln(os.Stderr, red("%v", err))
+				exit(1)
+			}
+			fmt.Fprintln(status, green("✔ Findings added to %s", *dbPath))
+		}
+
+		// A failed hook or webhook does not stop the scan, but fails the run
+		// like other operational errors.
+		notifyFailed := false
+		if *onFinding != "" && report.HasFindings() {
+			if err := RunFindingHook(ctx, *onFinding, *onFindingTimeout, report); err != nil {
+				fmt.Fprintln(os.Stderr, red("✖️ %v", err))
+				notifyFailed = true
+			} else {
+				fmt.Fprintln(status, green("✔ on-finding hook completed"))
+			}
+		}
+
+		if *emitSummaryStderr {
+			PrintSummaryLine(os.Stderr, apkFile, summaries, report.Native, len(methodSet))
+		}
+
+		if *webhook != "" {
+			secret := *webhookSecret
+			if secret == "" {
+				secret = os.Getenv("BOOLSEEKER_WEBHOOK_SECRET")
+			}
+			if err := PostWebhook(ctx, *webhook, secret, *webhookTimeout, *webhookRetries, report); err != nil {
+				fmt.Fprintln(os.Stderr, red("✖️ %v", err))
+				notifyFailed = true
+			} else {
+				fmt.Fprintln(status, green("✔ Findings delivered to %s", *webhook))
+			}
+		}
+
+		if len(results.Skipped) > 0 {
+			fmt.Fprintln(console, yellow("! %d smali files or directories could not be scanned", len(results.Skipped)))
+			if *verbose {
+				for _, skipped := range results.Skipped {
+					fmt.Fprintf(console, "  %s: %s\n", skipped.Path, skipped.Error)
 				}
+			} else {
+				fmt.Fprintln(console, yellow("  re-run with --verbose to list them"))
 			}
-			if len(filteredKeywords) > 0 {
-				foundKeywords = true
-				methodsWithKeywords[method] = filteredKeywords
+		}
+
+		var largeFiles []SkippedFile
+		if report.Native != nil {
+			largeFiles = append(largeFiles, report.Native.Skipped...)
+		}
+		if app.AppFiles != nil {
+			largeFiles = append(largeFiles, app.AppFiles.Skipped...)
+		}
+		if len(largeFiles) > 0 {
+			fmt.Fprintln(console, yellow("! %d .so or asset files were too large to search:", len(largeFiles)))
+			for _, skipped := range largeFiles {
+				fmt.Fprintf(console, "  %s: %s\n", skipped.Path, skipped.Error)
 			}
 		}
 
-		if foundKeywords {
-			fmt.Println("\033[33m✔ Java boolean methods containing keywords about File Integrity Checks:\033[0m")
-			for method, keywords := range methodsWithKeywords {
-				fmt.Printf("  \033[36m+ Java method: %s \033[0m- \033[31mKeywords found: %s\033[0m\n", method, strings.Join(keywords, ", "))
+		if notifyFailed {
+			return report, 1
+		}
+		if failOnCategories == nil {
+			return report, 0
+		}
+		return report, FindingsExitCode(report, nativeCategories, failOnCategories)
+	}
+
+	decodedDirectories := make(map[string]bool)
+	// workDirectories picks a unique name for an input and the directory to
+	// decode it into: a fresh temporary directory unless --out-dir is given,
+	// so boolseeker never removes a directory it did not create.
+	workDirectories := func(apkFile string) (name, workDirectory, decodedDirectory string) {
+		baseName := strings.TrimSuffix(strings.TrimSuffix(trimArchiveExt(filepath.Base(inputName(apkFile))), ".apk"), ".aab")
+		baseName = strings.TrimSuffix(baseName, ".dex")
+		name = baseName
+		for i := 2; decodedDirectories[name]; i++ {
+			name = fmt.Sprintf("%s_%d", baseName, i)
+		}
+		decodedDirectories[name] = true
+
+		workDirectory = *outDir
+		if workDirectory == "" {
+			workDirectory, err = os.MkdirTemp("", "boolseeker-")
+			if err != nil {
+				fmt.Fprintln(os.Stderr, red("✖️ Could not create a work directory: %v", err))
+				exit(1)
 			}
-			fmt.Println()
+		}
+		decodedDirectory = filepath.Join(workDirectory, name)
+		if _, err := os.Stat(decodedDirectory); err == nil {
+			fmt.Fprintln(os.Stderr, red("✖️ %s already exists; remove it or choose another --out-dir", decodedDirectory))
+			exit(1)
+		}
+		if *outDir != "" {
+			cleanups = append(cleanups, func() { CleanUp(decodedDirectory) })
 		} else {
-			fmt.Println("\033[31mX No keywords about File Integrity Checks found in Java boolean methods.\033[0m")
-			fmt.Println()
+			cleanups = append(cleanups, func() { CleanUp(workDirectory) })
 		}
+		return name, workDirectory, decodedDirectory
+	}
+	finish := func(workDirectory, decodedDirectory string) {
+		cleanups = nil
+		if *keep || *outDir != "" {
+			fmt.Fprintln(status, green("✔ Decoded output kept in %s", decodedDirectory))
+		} else {
+			CleanUp(workDirectory)
+		}
+	}
 
-	} else {
-		fmt.Println()
-		fmt.Println("\033[31mX No keywords found in Java boolean methods.\033[0m")
-		fmt.Println()
+	var baseline *Report
+	if *diffBaseline != "" {
+		_, workDirectory, decodedDirectory := workDirectories(*diffBaseline)
+		fmt.Fprintln(status, cyan("Scanning baseline %s...", *diffBaseline))
+		output, statusOutput := console, status
+		console, status = io.Discard, io.Discard
+		baseline, _ = analyze(AppInput{Base: *diffBaseline}, "", "", "", "", "", decodedDirectory, nil, true)
+		console, status = output, statusOutput
+		finish(workDirectory, decodedDirectory)
 	}
 
-	if *searchSo {
-		so_keywords := []string{"frida", "xposed", "su", "root", "magisk", "/sbin/su", "test-keys"}
-		err = SearchInSoFiles(decodedDirectory, so_keywords)
-		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+	exitCode := 0
+	aggregator := NewAggregator()
+	for _, input := range inputs {
+		var name, workDirectory, decodedDirectory string
+		if input.Decoded {
+			name = filepath.Base(input.Base)
+		} else {
+			name, workDirectory, decodedDirectory = workDirectories(input.Base)
+		}
+
+		outputPath, unmatchedOutPath, jsonOutPath, htmlOutPath, snapshotPath := *outputFile, *unmatchedOut, *jsonOut, *htmlOut, *incremental
+		if len(inputs) > 1 {
+			fmt.Fprintln(console, cyan("=== %s ===", input.Base))
+			outputPath = perInputPath(outputPath, name)
+			unmatchedOutPath = perInputPath(unmatchedOutPath, name)
+			jsonOutPath = perInputPath(jsonOutPath, name)
+			htmlOutPath = perInputPath(htmlOutPath, name)
+			snapshotPath = perInputPath(snapshotPath, name)
+		}
+
+		report, code := analyze(input, outputPath, unmatchedOutPath, jsonOutPath, htmlOutPath, snapshotPath, decodedDirectory, baseline, false)
+		aggregator.Add(report)
+		if code != 0 && (exitCode == 0 || code < exitCode) {
+			exitCode = code
 		}
+		if !input.Decoded {
+			finish(workDirectory, decodedDirectory)
+		}
+	}
+
+	switch *format {
+	case "json":
+		err = WriteJSONReports("", stdoutReports, *jsonPretty)
+	case "sarif":
+		err = WriteSARIFReport("", stdoutReports, *jsonPretty)
+	case "csv":
+		err = WriteCSVReport("", stdoutReports)
+	case "html":
+		err = WriteHTMLReport("", stdoutReports[0])
+	case "md":
+		err = WriteMarkdownReport("", stdoutReports)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 
-	CleanUp(decodedDirectory)
+	if len(inputs) > 1 || *aggregateOut != "" {
+		fleet := aggregator.Summary()
+		if len(inputs) > 1 {
+			PrintFleetSummary(fleet)
+		}
+		if *aggregateOut != "" {
+			if err := WriteFleetSummary(*aggregateOut, fleet, *jsonPretty); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			fmt.Fprintln(status, green("✔ Summary of %d apps written in %s", len(fleet.Apps), *aggregateOut))
+		}
+	}
+
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
 }
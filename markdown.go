@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// markdownEscaper escapes the characters Markdown would otherwise read as
+// formatting in plain text, such as the _ and $ of smali names.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", `\<`, ">", `\>`, "#", `\#`, "|", `\|`, "$", `\$`, "\n", " ",
+)
+
+// markdownCode returns value as an inline code span that stays in its table
+// cell: | is escaped, and a fence longer than any backtick run it contains
+// is used.
+func markdownCode(value string) string {
+	value = strings.ReplaceAll(strings.ReplaceAll(value, "\n", " "), "|", `\|`)
+	fence := "`"
+	for strings.Contains(value, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(value, "`") || strings.HasSuffix(value, "`") {
+		value = " " + value + " "
+	}
+	return fence + value + fence
+}
+
+// markdownLines returns each smali line of matches once, in file and line
+// order, as a method matching several keywords on one line has a match per
+// keyword.
+func markdownLines(matches []KeywordMatch) []string {
+	matches = slices.Clone(matches)
+	slices.SortStableFunc(matches, func(a, b KeywordMatch) int {
+		return cmp.Or(strings.Compare(a.File, b.File), a.Line-b.Line)
+	})
+	var lines []string
+	for _, match := range matches {
+		line := fmt.Sprintf("%s:%d: %s", match.File, match.Line, match.Context)
+		if !slices.Contains(lines, line) {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+var markdownReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"text":  markdownEscaper.Replace,
+	"code":  markdownCode,
+	"lines": markdownLines,
+}).Parse(`# boolseeker report: {{code .Report.APK}}
+
+{{if .Report.AppInfo.String}}- App: {{text .Report.AppInfo.String}}
+{{end}}{{if .Report.APKSHA256}}- SHA-256: {{code .Report.APKSHA256}}
+{{end}}{{if .Report.Framework}}- Framework: {{text .Report.Framework}}
+{{end}}{{if .Report.Protection}}- Protection SDKs: {{range $i, $sdk := .Report.Protection}}{{if $i}}, {{end}}{{text $sdk.Name}}{{end}}
+{{end}}{{if .Report.Verdict}}- Verdict: **{{.Report.Verdict.Coverage}}** anti-tamper coverage
+{{end}}- boolseeker {{text .Report.Version}}
+
+**{{.Report.TotalMethods}}** boolean methods, **{{.Matched}}** with keywords or detections.
+
+## Summary
+
+| Category | Methods | Keywords | Severity |
+| --- | ---: | ---: | --- |
+{{range .Report.Summary}}| {{text .Category}} | {{.Methods}} | {{.Keywords}} | {{.Severity}} |
+{{end}}
+## Findings
+{{range .Sections}}
+### {{text .Category}} ({{len .Rows}})
+{{if .Rows}}
+| Method | Score | Smali path | Matched |
+| --- | ---: | --- | --- |
+{{range .Rows}}| {{code .Method}} | {{.Score}} | {{code .SmaliPath}} | {{text .Matched}}{{range lines .Lines}}<br>{{code .}}{{end}} |
+{{end}}{{else}}
+_No findings._
+{{end}}{{end}}
+{{- if .Native}}
+## .so files ({{len .Native}})
+
+| File | Keywords |
+| --- | --- |
+{{range .Native}}| {{code .File}} | {{text .Keywords}} |
+{{end}}{{end}}
+{{- if .AppFiles}}
+## Manifest and assets ({{len .AppFiles}})
+
+| File | Keywords |
+| --- | --- |
+{{range .AppFiles}}| {{code .File}} | {{text .Keywords}} |
+{{end}}{{end}}
+{{- if .Resources}}
+## String resources ({{len .Resources}})
+
+| Resource | Keywords |
+| --- | --- |
+{{range .Resources}}| {{code .File}} | {{text .Keywords}} |
+{{end}}{{end}}`))
+
+// WriteMarkdownReport writes reports as a Markdown document, a top-level
+// section per report with the findings grouped as in the HTML report, to
+// stdout when path is empty or "-".
+func WriteMarkdownReport(path string, reports []*Report) error {
+	var content bytes.Buffer
+	for i, report := range reports {
+		if i > 0 {
+			content.WriteString("\n")
+		}
+		if err := markdownReportTemplate.Execute(&content, newHTMLPage(report)); err != nil {
+			return err
+		}
+	}
+
+	if path == "" || path == "-" {
+		_, err := os.Stdout.Write(content.Bytes())
+		return err
+	}
+	return os.WriteFile(path, content.Bytes(), 0644)
+}
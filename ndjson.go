@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"regexp"
+	"sync"
+
+	"github.com/0xdeny/boolseeker/pkg/scanner"
+)
+
+// ndjsonFinding is one line of --format ndjson output.
+type ndjsonFinding struct {
+	APK string `json:"apk"`
+	Finding
+}
+
+// FindingStream writes findings as newline-delimited JSON while the smali
+// scan runs, applying --min-score and --ignore as the report does. Each
+// method is written once, the first time it is found.
+type FindingStream struct {
+	mu       sync.Mutex
+	encoder  *json.Encoder
+	apk      string
+	minScore int
+	ignore   []*regexp.Regexp
+	seen     map[string]struct{}
+}
+
+func NewFindingStream(w io.Writer, apk string, minScore int, ignore []*regexp.Regexp) *FindingStream {
+	return &FindingStream{encoder: json.NewEncoder(w), apk: apk, minScore: minScore, ignore: ignore, seen: make(map[string]struct{})}
+}
+
+// Write writes one finding; it is safe for concurrent use.
+func (s *FindingStream) Write(finding Finding) {
+	results := &scanner.SmaliResults{Findings: []Finding{finding}}
+	if s.minScore > 0 {
+		results.DropBelowScore(s.minScore)
+	}
+	if s.ignore != nil {
+		results.Ignore(s.ignore)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, dup := s.seen[finding.Method]; dup {
+		return
+	}
+	s.seen[finding.Method] = struct{}{}
+	s.encoder.Encode(ndjsonFinding{s.apk, results.Findings[0]})
+}
@@ -0,0 +1,153 @@
+package scanner
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"time"
+)
+
+// AppOptions selects what ScanApp searches besides the smali methods, and
+// how findings are filtered.
+type AppOptions struct {
+	// APKFile is the APK the directory was decoded from. It is checked for
+	// signs of packing and its .so files are searched with Native; it is
+	// empty when only the decoded directory is at hand.
+	APKFile string
+	// Manifest and Assets search AndroidManifest.xml and the files below
+	// assets/, see SearchAppFiles.
+	Manifest, Assets bool
+	// Resources searches the string resources, see SearchStringResources.
+	Resources bool
+	// EarlyInit and Callers trace the smali call graph, see
+	// FindEarlyInitChecks and FindCallers.
+	EarlyInit, Callers bool
+	// Native searches the .so files for the keywords of NativeCategories,
+	// or of the Scanner's categories when NativeCategories is nil.
+	Native           bool
+	NativeCategories []KeywordCategory
+	// MinScore and Ignore clear the findings scoring below MinScore and
+	// those of the methods Ignore matches.
+	MinScore   int
+	Ignore     []*regexp.Regexp
+	Thresholds VerdictThresholds
+	// Cache and Progress are passed on to FindBooleanMethodsInSmali and may
+	// be nil.
+	Cache    Cache
+	Progress Progress
+}
+
+// App is what ScanApp found in a decoded APK.
+type App struct {
+	Smali *SmaliResults
+	// SmaliFiles is the number of smali files scanned and Suppressed the
+	// number of methods cleared by AppOptions.Ignore.
+	SmaliFiles int
+	Suppressed int
+	// Packing describes the signs that the app is packed, see
+	// DetectPacking.
+	Packing    []string
+	Protection []ProtectionSDK
+	// Summaries are those of Summarize, followed by the manifest and asset
+	// and the string resource rows when they were searched.
+	Summaries []CategorySummary
+	Verdict   *Verdict
+	AppFiles  *AppFileResults
+	Resources []ResourceMatch
+	EarlyInit map[string][]string
+	Callers   map[string][]string
+	Native    *NativeResults
+}
+
+// ScanApp runs every scan opts asks for over the decoded APK in directory:
+// the boolean methods of all its smali directories, scored and filtered,
+// then the summaries and verdict, the manifest, assets and resources, the
+// call graph and the native libraries. It stops early with ctx's error when
+// ctx is cancelled.
+func (s *Scanner) ScanApp(ctx context.Context, directory string, opts AppOptions) (*App, error) {
+	smaliDirs, err := FindSmaliDirs(directory, s.opts.FollowSymlinks)
+	if err != nil {
+		return nil, err
+	}
+	app := &App{Smali: NewSmaliResults()}
+	if opts.APKFile != "" && !IsRawDex(opts.APKFile) {
+		if app.Packing, err = DetectPacking(opts.APKFile, smaliDirs); err != nil {
+			slog.Warn("could not check the APK for packing", "apk", opts.APKFile, "error", err)
+		}
+	}
+	app.Protection = DetectProtectionSDKs(smaliDirs, s.opts.FollowSymlinks)
+
+	for _, smaliDir := range smaliDirs {
+		paths, _, err := SmaliFiles(smaliDir, s.opts.Packages, s.opts.FollowSymlinks)
+		if err != nil {
+			return nil, err
+		}
+		app.SmaliFiles += len(paths)
+	}
+	if opts.Progress != nil {
+		opts.Progress.Count("Searching for Java boolean methods and keywords in "+directory, app.SmaliFiles)
+	}
+	scanStart := time.Now()
+	for _, smaliDir := range smaliDirs {
+		results, err := s.FindBooleanMethodsInSmali(ctx, smaliDir, opts.Cache, opts.Progress)
+		if err != nil {
+			return nil, err
+		}
+		app.Smali.Merge(results)
+	}
+	slog.Info("scanned smali", "directories", len(smaliDirs), "files", app.SmaliFiles, "methods", len(app.Smali.Findings), "duration", time.Since(scanStart))
+
+	s.Score(app.Smali)
+	if opts.MinScore > 0 {
+		app.Smali.DropBelowScore(opts.MinScore)
+	}
+	if opts.Ignore != nil {
+		app.Suppressed = app.Smali.Ignore(opts.Ignore)
+	}
+
+	app.Summaries = s.Summarize(app.Smali)
+	if opts.Manifest || opts.Assets {
+		if app.AppFiles, err = s.SearchAppFiles(directory, opts.Manifest, opts.Assets); err != nil {
+			return nil, err
+		}
+		app.Summaries = append(app.Summaries, app.AppFiles.Summary())
+	}
+	if opts.Resources {
+		if app.Resources, err = s.SearchStringResources(directory); err != nil {
+			return nil, err
+		}
+		app.Summaries = append(app.Summaries, ResourcesSummary(app.Resources))
+	}
+	app.Verdict = NewVerdict(app.Summaries, s.opts.Categories, opts.Thresholds)
+
+	if opts.EarlyInit || opts.Callers {
+		graph, err := BuildMethodGraph(smaliDirs, s.opts.FollowSymlinks)
+		if err != nil {
+			return nil, err
+		}
+		if opts.EarlyInit {
+			app.EarlyInit = FindEarlyInitChecks(graph, app.Smali.Methods(), s.opts)
+		}
+		if opts.Callers {
+			keywords, detections := app.Smali.Keywords(), app.Smali.Detections()
+			var matched []string
+			for _, method := range app.Smali.Methods() {
+				if len(keywords[method]) > 0 || len(detections[method]) > 0 {
+					matched = append(matched, method)
+				}
+			}
+			app.Callers = FindCallers(graph, matched, s.opts)
+		}
+	}
+
+	if opts.Native {
+		categories := opts.NativeCategories
+		if categories == nil {
+			categories = s.opts.Categories
+		}
+		if app.Native, err = s.SearchInSoFiles(ctx, directory, opts.APKFile, CategoryKeywordUnion(categories), opts.Progress); err != nil {
+			return nil, err
+		}
+	}
+	return app, nil
+}
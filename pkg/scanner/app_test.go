@@ -0,0 +1,104 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestScanApp(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"smali/com/app/App.smali": `.class public Lcom/app/App;
+.super Landroid/app/Application;
+
+.method public onCreate()V
+    invoke-static {}, Lcom/app/Root;->isRooted()Z
+    return-void
+.end method
+`,
+		"smali_classes2/com/app/Root.smali": `.class public Lcom/app/Root;
+.super Ljava/lang/Object;
+
+.method public static isRooted()Z
+    const-string v0, "/system/xbin/su"
+    const v1, 0x7f0e0001
+    const/4 v0, 0x1
+    return v0
+.end method
+
+.method public static isReady()Z
+    const/4 v0, 0x0
+    return v0
+.end method
+`,
+		"smali/com/scottyab/rootbeer/RootBeer.smali": ".class public Lcom/scottyab/rootbeer/RootBeer;\n.super Ljava/lang/Object;\n",
+		"AndroidManifest.xml":                        `<manifest android:debuggable="true"/>`,
+		"res/values/public.xml":                      `<resources><public type="string" name="sig" id="0x7f0e0001"/></resources>`,
+		"res/values/strings.xml": `<resources>
+    <string name="sig">d41d8cd98f00b204e9800998ecf8427e</string>
+    <string name="hint">looking for magisk</string>
+</resources>`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scan, err := New(DefaultOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scan, err = scan.WithResourceChecksums(dir); err != nil {
+		t.Fatal(err)
+	}
+	app, err := scan.ScanApp(context.Background(), dir, AppOptions{Manifest: true, Resources: true, EarlyInit: true, Callers: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const isRooted = "com.app.Root.isRooted()"
+	if app.SmaliFiles != 3 {
+		t.Errorf("SmaliFiles = %d, want 3", app.SmaliFiles)
+	}
+	if got := app.Smali.Keywords()[isRooted]; !slices.Contains(got, "/system/xbin/su") {
+		t.Errorf("keywords of %s = %v, want /system/xbin/su among them", isRooted, got)
+	}
+	if got := app.Smali.Detections()[isRooted]; !slices.ContainsFunc(got, func(d Detection) bool { return d.Detector == "Resource Integrity Check" }) {
+		t.Errorf("detections of %s = %v, want the resource integrity check", isRooted, got)
+	}
+	if got := app.EarlyInit[isRooted]; !slices.Equal(got, []string{"Lcom/app/App;->onCreate()V"}) {
+		t.Errorf("early init of %s = %v", isRooted, got)
+	}
+	if got := app.Callers[isRooted]; !slices.Equal(got, []string{"com.app.App.onCreate()"}) {
+		t.Errorf("callers of %s = %v, want com.app.App.onCreate()", isRooted, got)
+	}
+	if _, ok := app.Callers["com.app.Root.isReady()"]; ok {
+		t.Error("callers were looked up for a method without keywords")
+	}
+	if len(app.Protection) != 1 || app.Protection[0].Name != "RootBeer" {
+		t.Errorf("Protection = %v, want RootBeer", app.Protection)
+	}
+	if got := app.AppFiles.Keywords["/AndroidManifest.xml"]; !slices.Equal(got, []string{"android:debuggable"}) {
+		t.Errorf("manifest keywords = %v, want android:debuggable", got)
+	}
+	if len(app.Resources) != 1 || app.Resources[0].Resource != "string/hint" {
+		t.Errorf("Resources = %v, want string/hint", app.Resources)
+	}
+	if !slices.ContainsFunc(app.Summaries, func(s CategorySummary) bool { return s.ID == ResourcesCategory.ID && s.Methods == 1 }) {
+		t.Errorf("Summaries = %v, want a string resources row", app.Summaries)
+	}
+	if app.Verdict == nil || app.Verdict.Coverage == "NONE" {
+		t.Errorf("Verdict = %+v, want the root category found", app.Verdict)
+	}
+	if app.Native != nil {
+		t.Error("native libraries were searched without AppOptions.Native")
+	}
+}
@@ -0,0 +1,119 @@
+package scanner
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ManifestSignalKeywords are strings that point at integrity or anti-tamper
+// configuration when they show up in the manifest or in assets.
+var ManifestSignalKeywords = []string{"REQUEST_INSTALL_PACKAGES", "QUERY_ALL_PACKAGES", "com.google.android.play.core.integrity", "com.google.android.gms.safetynet", "SafetyNet", "PlayIntegrity", "integrity_token", "android:debuggable", "android:testOnly", "android:extractNativeLibs"}
+
+// AppFilesCategory is the summary row of the manifest and asset matches.
+var AppFilesCategory = KeywordCategory{ID: "app_files", Name: "Manifest and Asset Signals", Keywords: ManifestSignalKeywords, Severity: "medium"}
+
+// maxAppFileSize skips large assets such as media and bundled databases,
+// as each asset is read whole.
+const maxAppFileSize = 16 << 20
+
+// AppFileResults maps the manifest and asset files to the keywords found in
+// them, by path relative to the decoded APK.
+type AppFileResults struct {
+	Keywords map[string][]string `json:"keywords"`
+	// Skipped are the assets left out for their size.
+	Skipped []SkippedFile `json:"skipped,omitempty"`
+}
+
+// binaryXMLHeader starts compiled Android XML, as found in APKs decoded with
+// --engine dex; its strings are UTF-16LE.
+var binaryXMLHeader = []byte{0x03, 0x00, 0x08, 0x00}
+
+// SearchAppFiles looks for the keywords of s and ManifestSignalKeywords in
+// AndroidManifest.xml of the decoded APK in directory and, with assets, in
+// every file below assets/. Keywords only match on word boundaries, so a
+// short keyword such as su does not match supportsRtl. Assets larger than
+// Options.MaxFileSize, or than maxAppFileSize, are skipped.
+func (s *Scanner) SearchAppFiles(directory string, manifest, assets bool) (*AppFileResults, error) {
+	results := &AppFileResults{Keywords: map[string][]string{}}
+	limit := int64(maxAppFileSize)
+	if s.opts.MaxFileSize > 0 {
+		limit = min(limit, s.opts.MaxFileSize)
+	}
+	keywords := append(slices.Clone(s.keywords), ManifestSignalKeywords...)
+
+	var paths []string
+	if manifest {
+		paths = append(paths, filepath.Join(directory, "AndroidManifest.xml"))
+	}
+	if assets {
+		err := Walk(filepath.Join(directory, "assets"), s.opts.FollowSymlinks, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+			if info.Size() > limit {
+				slog.Info("skipping large asset", "file", path, "size", info.Size(), "limit", limit)
+				results.Skipped = append(results.Skipped, SkippedFile{Path: filepath.ToSlash(strings.TrimPrefix(path, filepath.Join(directory))), Error: fmt.Sprintf("%d bytes, over the %d byte limit", info.Size(), limit)})
+				return nil
+			}
+			paths = append(paths, path)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		if bytes.HasPrefix(content, binaryXMLHeader) {
+			content = bytes.ReplaceAll(content, []byte{0}, nil)
+		}
+
+		text := string(content)
+		lowerText := strings.ToLower(text)
+		relativePath := filepath.ToSlash(strings.TrimPrefix(path, filepath.Join(directory)))
+		for _, keyword := range keywords {
+			found := false
+			if pattern, ok := s.Pattern(keyword); ok {
+				found = pattern.MatchString(text)
+			} else {
+				found = KeywordIndex(lowerText, strings.ToLower(keyword), true) >= 0
+			}
+			if found {
+				results.Keywords[relativePath] = append(results.Keywords[relativePath], keyword)
+			}
+		}
+	}
+	return results, nil
+}
+
+// Summary counts the files and distinct keywords found, for the summary
+// table.
+func (r *AppFileResults) Summary() CategorySummary {
+	var keywords []string
+	for _, found := range r.Keywords {
+		for _, keyword := range found {
+			if !slices.Contains(keywords, keyword) {
+				keywords = append(keywords, keyword)
+			}
+		}
+	}
+	return CategorySummary{ID: AppFilesCategory.ID, Category: AppFilesCategory.Name, Methods: len(r.Keywords), Keywords: len(keywords), Severity: AppFilesCategory.Severity}
+}
@@ -0,0 +1,189 @@
+package scanner
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const earlyInitTraceDepth = 4
+
+var (
+	invokePattern = regexp.MustCompile(`invoke-[\w/-]+ \{[^}]*\}, (L[^;]+;->[^(\s]+\([^)]*\)\S+)`)
+
+	applicationClasses = []string{"Landroid/app/Application;", "Landroidx/multidex/MultiDexApplication;", "Landroid/support/multidex/MultiDexApplication;"}
+	providerClasses    = []string{"Landroid/content/ContentProvider;"}
+
+	applicationEntryPoints = []string{"attachBaseContext(Landroid/content/Context;)V", "onCreate()V"}
+	providerEntryPoints    = []string{"onCreate()Z", "attachInfo(Landroid/content/Context;Landroid/content/pm/ProviderInfo;)V"}
+)
+
+// MethodGraph holds the superclass of each smali class and the methods each
+// smali method invokes, by descriptor such as Lcom/app/Foo;->check()Z.
+type MethodGraph struct {
+	supers map[string]string
+	calls  map[string][]string
+}
+
+func lastField(line string) string {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[len(fields)-1]
+}
+
+// BuildMethodGraph reads the classes and invocations of every smali file in
+// smaliDirs. Symbolic links are followed as by Walk.
+func BuildMethodGraph(smaliDirs []string, followSymlinks bool) (*MethodGraph, error) {
+	graph := &MethodGraph{
+		supers: make(map[string]string),
+		calls:  make(map[string][]string),
+	}
+
+	for _, smaliDir := range smaliDirs {
+		err := Walk(smaliDir, followSymlinks, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() || !strings.HasSuffix(info.Name(), ".smali") {
+				return nil
+			}
+
+			file, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			var class, method string
+			lines := bufio.NewScanner(file)
+			lines.Buffer(make([]byte, 1<<20), 1<<24)
+			for lines.Scan() {
+				line := strings.TrimSpace(lines.Text())
+				switch {
+				case strings.HasPrefix(line, ".class "):
+					class = lastField(line)
+				case strings.HasPrefix(line, ".super "):
+					graph.supers[class] = lastField(line)
+				case strings.HasPrefix(line, ".method "):
+					method = class + "->" + lastField(line)
+				case strings.HasPrefix(line, ".end method"):
+					method = ""
+				case method != "" && strings.HasPrefix(line, "invoke-"):
+					if match := invokePattern.FindStringSubmatch(line); match != nil && !slices.Contains(graph.calls[method], match[1]) {
+						graph.calls[method] = append(graph.calls[method], match[1])
+					}
+				}
+			}
+			return lines.Err()
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return graph, nil
+}
+
+func (g *MethodGraph) extends(class string, bases []string) bool {
+	for i := 0; i < 32 && class != ""; i++ {
+		if slices.Contains(bases, class) {
+			return true
+		}
+		class = g.supers[class]
+	}
+	return false
+}
+
+func (g *MethodGraph) entryPoints() []string {
+	var entries []string
+	for class := range g.supers {
+		var names []string
+		switch {
+		case g.extends(class, applicationClasses):
+			names = applicationEntryPoints
+		case g.extends(class, providerClasses):
+			names = providerEntryPoints
+		}
+		for _, name := range names {
+			if _, defined := g.calls[class+"->"+name]; defined {
+				entries = append(entries, class+"->"+name)
+			}
+		}
+	}
+	slices.Sort(entries)
+	return entries
+}
+
+func descriptorMethodName(descriptor string, opts Options) (string, bool) {
+	classEnd := strings.Index(descriptor, ";->")
+	paren := strings.Index(descriptor, "(")
+	if !strings.HasPrefix(descriptor, "L") || classEnd < 0 || paren < classEnd {
+		return "", false
+	}
+	return FormatMethodName(descriptor[1:classEnd], descriptor[classEnd+3:paren], descriptor[paren:], opts), true
+}
+
+// FindEarlyInitChecks traces invocations from Application.attachBaseContext,
+// Application.onCreate and ContentProvider.onCreate, which run before any
+// activity, and returns the reported boolean methods reachable from them
+// mapped to the entry points that reach them.
+func FindEarlyInitChecks(graph *MethodGraph, booleanMethods []string, opts Options) map[string][]string {
+	reported := make(map[string]struct{})
+	for _, method := range booleanMethods {
+		reported[method] = struct{}{}
+	}
+
+	checks := make(map[string][]string)
+	for _, entry := range graph.entryPoints() {
+		visited := map[string]bool{entry: true}
+		frontier := []string{entry}
+		for depth := 0; depth < earlyInitTraceDepth && len(frontier) > 0; depth++ {
+			var next []string
+			for _, caller := range frontier {
+				for _, callee := range graph.calls[caller] {
+					if visited[callee] {
+						continue
+					}
+					visited[callee] = true
+					next = append(next, callee)
+
+					name, ok := descriptorMethodName(callee, opts)
+					if _, isReported := reported[name]; ok && isReported && !slices.Contains(checks[name], entry) {
+						checks[name] = append(checks[name], entry)
+					}
+				}
+			}
+			frontier = next
+		}
+	}
+	return checks
+}
+
+// FindCallers maps each of methods to the methods that invoke it directly,
+// sorted. Methods nobody invokes map to an empty list: they are dead code or
+// only reached through reflection or a subclass or interface reference.
+func FindCallers(graph *MethodGraph, methods []string, opts Options) map[string][]string {
+	callers := make(map[string][]string, len(methods))
+	for _, method := range methods {
+		callers[method] = []string{}
+	}
+	for caller, callees := range graph.calls {
+		callerName, ok := descriptorMethodName(caller, opts)
+		if !ok {
+			continue
+		}
+		for _, callee := range callees {
+			name, ok := descriptorMethodName(callee, opts)
+			if _, wanted := callers[name]; ok && wanted && name != callerName && !slices.Contains(callers[name], callerName) {
+				callers[name] = append(callers[name], callerName)
+			}
+		}
+	}
+	for _, list := range callers {
+		slices.Sort(list)
+	}
+	return callers
+}
@@ -0,0 +1,723 @@
+package scanner
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// demangleSymbol returns the readable form of an Itanium C++ mangled symbol
+// such as _ZN4Root5checkEv (Root::check()), or false when symbol is not
+// mangled or uses constructs the demangler does not support: expressions,
+// local names, thunks and the like keep their mangled form. Names read as
+// GNU c++filt prints them, and what c++filt rejects is rejected too.
+func demangleSymbol(symbol string) (string, bool) {
+	if !strings.HasPrefix(symbol, "_Z") {
+		return "", false
+	}
+	// Mangled names never contain dots; GCC appends clone suffixes such as
+	// .cold or .constprop.0 after them.
+	body, clones, _ := strings.Cut(symbol[2:], ".")
+
+	d := &demangler{input: body}
+	name, err := d.encoding()
+	if err != nil || d.pos != len(d.input) {
+		return "", false
+	}
+	name = finish(name)
+	if clones != "" {
+		name += " [clone ." + clones + "]"
+	}
+	return name, true
+}
+
+var errUnsupported = errors.New("unsupported mangled name")
+
+var builtinTypes = map[byte]string{
+	'v': "void", 'w': "wchar_t", 'b': "bool", 'c': "char", 'a': "signed char",
+	'h': "unsigned char", 's': "short", 't': "unsigned short", 'i': "int",
+	'j': "unsigned int", 'l': "long", 'm': "unsigned long", 'x': "long long",
+	'y': "unsigned long long", 'n': "__int128", 'o': "unsigned __int128",
+	'f': "float", 'd': "double", 'e': "long double", 'g': "__float128", 'z': "...",
+}
+
+var builtinDTypes = map[byte]string{
+	'n': "decltype(nullptr)", 'i': "char32_t", 's': "char16_t", 'u': "char8_t",
+	'a': "auto", 'c': "decltype(auto)",
+}
+
+// substitutionAbbreviations are spelled out in full, as c++filt does.
+var substitutionAbbreviations = map[byte]string{
+	'a': "std::allocator", 'b': "std::basic_string",
+	's': "std::basic_string<char, std::char_traits<char>, std::allocator<char> >",
+	'i': "std::basic_istream<char, std::char_traits<char> >",
+	'o': "std::basic_ostream<char, std::char_traits<char> >",
+	'd': "std::basic_iostream<char, std::char_traits<char> >",
+}
+
+var operatorNames = map[string]string{
+	"nw": "new", "na": "new[]", "dl": "delete", "da": "delete[]", "ps": "+",
+	"ng": "-", "ad": "&", "de": "*", "co": "~", "pl": "+", "mi": "-", "ml": "*",
+	"dv": "/", "rm": "%", "an": "&", "or": "|", "eo": "^", "aS": "=", "pL": "+=",
+	"mI": "-=", "mL": "*=", "dV": "/=", "rM": "%=", "aN": "&=", "oR": "|=",
+	"eO": "^=", "ls": "<<", "rs": ">>", "lS": "<<=", "rS": ">>=", "eq": "==",
+	"ne": "!=", "lt": "<", "gt": ">", "le": "<=", "ge": ">=", "ss": "<=>",
+	"nt": "!", "aa": "&&", "oo": "||", "pp": "++", "mm": "--", "cm": ",",
+	"pm": "->*", "pt": "->", "cl": "()", "ix": "[]",
+}
+
+// demangler parses the subset of the Itanium C++ ABI mangling grammar found
+// in the symbols of typical NDK libraries. Function and array types are
+// rendered with a \x00 placeholder where a pointer or reference declarator
+// goes, so a pointer to void(int) becomes void (*)(int).
+type demangler struct {
+	input        string
+	pos          int
+	subs         []string
+	templateArgs []templateArg
+
+	// Inside a pack expansion, packElement is the element of the argument
+	// pack being rendered, and packSize the size of the pack, or -1 until
+	// one is found.
+	expanding   bool
+	packElement int
+	packSize    int
+}
+
+// templateArg is a rendered template argument; an argument pack also keeps
+// its elements, for pack expansions.
+type templateArg struct {
+	text string
+	pack []string
+}
+
+func (d *demangler) peek(offset int) byte {
+	if d.pos+offset < len(d.input) {
+		return d.input[d.pos+offset]
+	}
+	return 0
+}
+
+func (d *demangler) consume(prefix string) bool {
+	if strings.HasPrefix(d.input[d.pos:], prefix) {
+		d.pos += len(prefix)
+		return true
+	}
+	return false
+}
+
+func (d *demangler) encoding() (string, error) {
+	for prefix, label := range map[string]string{"TV": "vtable for ", "TI": "typeinfo for ", "TS": "typeinfo name for ", "TT": "VTT for "} {
+		if d.consume(prefix) {
+			t, err := d.typ()
+			return label + t, err
+		}
+	}
+	if d.consume("GV") {
+		name, _, _, err := d.name(true)
+		return "guard variable for " + name, err
+	}
+
+	name, template, qualifiers, err := d.name(true)
+	if err != nil || d.pos == len(d.input) {
+		return name, err
+	}
+	var result string
+	if template {
+		if result, err = d.typ(); err != nil {
+			return "", err
+		}
+	}
+	var params []string
+	for d.pos < len(d.input) {
+		param, err := d.typ()
+		if err != nil {
+			return "", err
+		}
+		if param != "" {
+			params = append(params, param)
+		}
+	}
+	if len(params) == 1 && params[0] == "void" {
+		params = nil
+	}
+	name += "(" + strings.Join(params, ", ") + ")" + qualifiers
+	if result != "" {
+		name = result + " " + name
+	}
+	return name, nil
+}
+
+// name parses a function or variable name and reports whether it ends with
+// template arguments, which means the function type starts with its return
+// type, and the cv- and ref-qualifiers of a member function. With top, the
+// template arguments become those T_ refers to.
+func (d *demangler) name(top bool) (string, bool, string, error) {
+	switch {
+	case d.peek(0) == 'N':
+		return d.nestedName(top)
+	case d.peek(0) == 'Z':
+		return "", false, "", errUnsupported
+	case d.peek(0) == 'S' && d.peek(1) != 't':
+		sub, err := d.substitution()
+		if err != nil || d.peek(0) != 'I' {
+			return sub, false, "", errUnsupported
+		}
+		args, err := d.templateArgList(top)
+		return withArgs(sub, args), true, "", err
+	}
+
+	prefix := ""
+	if d.consume("St") {
+		prefix = "std::"
+	}
+	d.consume("L")
+	unqualified, err := d.unqualifiedName("")
+	if err != nil {
+		return "", false, "", err
+	}
+	name := prefix + unqualified
+	if d.peek(0) != 'I' {
+		return name, false, "", nil
+	}
+	d.subs = append(d.subs, name)
+	args, err := d.templateArgList(top)
+	return withArgs(name, args), true, "", err
+}
+
+func (d *demangler) nestedName(top bool) (string, bool, string, error) {
+	d.pos++
+	var qualifiers string
+	for _, q := range []struct{ code, text string }{{"r", " restrict"}, {"V", " volatile"}, {"K", " const"}} {
+		if d.consume(q.code) {
+			qualifiers += q.text
+		}
+	}
+	if d.consume("R") {
+		qualifiers += " &"
+	} else if d.consume("O") {
+		qualifiers += " &&"
+	}
+
+	// Constructors, destructors and conversion operators have no return
+	// type, even when they are templates.
+	soFar, template, noReturnType := "", false, false
+	if d.consume("St") {
+		soFar = "std"
+	}
+	join := func(component string) {
+		if soFar == "" {
+			soFar = component
+		} else {
+			soFar += "::" + component
+		}
+	}
+	for !d.consume("E") {
+		if d.pos >= len(d.input) {
+			return "", false, "", errUnsupported
+		}
+		d.consume("L")
+		template = false
+		switch {
+		case d.peek(0) == 'I':
+			if soFar == "" {
+				return "", false, "", errUnsupported
+			}
+			args, err := d.templateArgList(top)
+			if err != nil {
+				return "", false, "", err
+			}
+			soFar = withArgs(soFar, args)
+			template = !noReturnType
+		case d.peek(0) == 'T':
+			param, err := d.templateParam()
+			if err != nil {
+				return "", false, "", err
+			}
+			join(param)
+		case d.peek(0) == 'S':
+			sub, err := d.substitution()
+			if err != nil {
+				return "", false, "", err
+			}
+			join(sub)
+			if soFar == sub {
+				continue
+			}
+		default:
+			noReturnType = d.peek(0) == 'C' || d.peek(0) == 'D' || d.peek(0) == 'c' && d.peek(1) == 'v'
+			component, err := d.unqualifiedName(soFar)
+			if err != nil {
+				return "", false, "", err
+			}
+			join(component)
+			d.subs = append(d.subs, soFar)
+			continue
+		}
+		noReturnType = false
+		d.subs = append(d.subs, soFar)
+	}
+	if soFar == "" || len(d.subs) == 0 {
+		return "", false, "", errUnsupported
+	}
+	d.subs = d.subs[:len(d.subs)-1]
+	return soFar, template, qualifiers, nil
+}
+
+// unqualifiedName parses a source name, an operator name or, for the class
+// named by scope, a constructor or destructor name, with its ABI tags.
+func (d *demangler) unqualifiedName(scope string) (string, error) {
+	var name string
+	switch c := d.peek(0); {
+	case c >= '0' && c <= '9':
+		source, err := d.sourceName()
+		if err != nil {
+			return "", err
+		}
+		name = source
+		if strings.HasPrefix(name, "_GLOBAL__N") {
+			name = "(anonymous namespace)"
+		}
+	case c == 'C' && (d.peek(1) >= '1' && d.peek(1) <= '5' || d.peek(1) == 'I'):
+		d.pos += 2
+		name = baseName(scope)
+		// c++filt rejects ABI tags on constructors and destructors, as
+		// libc++ emits them; they keep their mangled form as there.
+		if d.peek(0) == 'B' {
+			return "", errUnsupported
+		}
+	case c == 'D' && d.peek(1) >= '0' && d.peek(1) <= '5':
+		d.pos += 2
+		name = "~" + baseName(scope)
+		if d.peek(0) == 'B' {
+			return "", errUnsupported
+		}
+	case c == 'c' && d.peek(1) == 'v':
+		d.pos += 2
+		t, err := d.typ()
+		if err != nil {
+			return "", err
+		}
+		name = "operator " + t
+	default:
+		operator, ok := operatorNames[d.input[d.pos:min(d.pos+2, len(d.input))]]
+		if !ok {
+			return "", errUnsupported
+		}
+		d.pos += 2
+		name = "operator" + operator
+		if operator[0] >= 'a' && operator[0] <= 'z' {
+			name = "operator " + operator
+		}
+	}
+	for d.consume("B") {
+		tag, err := d.sourceName()
+		if err != nil {
+			return "", err
+		}
+		name += "[abi:" + tag + "]"
+	}
+	return name, nil
+}
+
+// baseName returns the last component of a qualified name without its
+// template arguments, the name of its constructors.
+func baseName(scope string) string {
+	for strings.HasSuffix(scope, ">") {
+		depth := 0
+		for i := len(scope) - 1; i >= 0; i-- {
+			if scope[i] == '>' {
+				depth++
+			} else if scope[i] == '<' {
+				if depth--; depth == 0 {
+					scope = scope[:i]
+					break
+				}
+			}
+		}
+		if depth != 0 {
+			break
+		}
+	}
+	if i := strings.LastIndex(scope, "::"); i >= 0 {
+		scope = scope[i+2:]
+	}
+	name, _, _ := strings.Cut(scope, "[abi:")
+	return name
+}
+
+func (d *demangler) number() (int, error) {
+	start := d.pos
+	for d.pos < len(d.input) && d.input[d.pos] >= '0' && d.input[d.pos] <= '9' {
+		d.pos++
+	}
+	return strconv.Atoi(d.input[start:d.pos])
+}
+
+func (d *demangler) sourceName() (string, error) {
+	length, err := d.number()
+	if err != nil || length <= 0 || d.pos+length > len(d.input) {
+		return "", errUnsupported
+	}
+	name := d.input[d.pos : d.pos+length]
+	d.pos += length
+	return name, nil
+}
+
+func (d *demangler) seqID(terminator byte) (int, error) {
+	if d.peek(0) == terminator {
+		d.pos++
+		return 0, nil
+	}
+	start := d.pos
+	for d.pos < len(d.input) && d.input[d.pos] != terminator {
+		d.pos++
+	}
+	if d.pos >= len(d.input) {
+		return 0, errUnsupported
+	}
+	id, err := strconv.ParseUint(d.input[start:d.pos], 36, 32)
+	if err != nil || strings.ToUpper(d.input[start:d.pos]) != d.input[start:d.pos] {
+		return 0, errUnsupported
+	}
+	d.pos++
+	return int(id) + 1, nil
+}
+
+func (d *demangler) substitution() (string, error) {
+	d.pos++
+	if abbreviation, ok := substitutionAbbreviations[d.peek(0)]; ok {
+		d.pos++
+		return abbreviation, nil
+	}
+	index, err := d.seqID('_')
+	if err != nil || index >= len(d.subs) {
+		return "", errUnsupported
+	}
+	return d.subs[index], nil
+}
+
+// templateParam resolves a template parameter; inside a pack expansion, an
+// argument pack resolves to the element being rendered.
+func (d *demangler) templateParam() (string, error) {
+	d.pos++
+	index, err := d.seqID('_')
+	if err != nil || index >= len(d.templateArgs) {
+		return "", errUnsupported
+	}
+	arg := d.templateArgs[index]
+	if arg.pack == nil || !d.expanding {
+		return arg.text, nil
+	}
+	d.packSize = len(arg.pack)
+	if d.packElement >= len(arg.pack) {
+		return "", nil
+	}
+	return arg.pack[d.packElement], nil
+}
+
+// packExpansion renders the type of a pack expansion once for each element
+// of the argument pack it refers to, as c++filt does.
+func (d *demangler) packExpansion() (string, error) {
+	start, subs := d.pos, len(d.subs)
+	expanding, element, size := d.expanding, d.packElement, d.packSize
+	defer func() { d.expanding, d.packElement, d.packSize = expanding, element, size }()
+
+	var elements []string
+	d.expanding, d.packElement, d.packSize = true, 0, -1
+	for {
+		t, err := d.typ()
+		if err != nil {
+			return "", err
+		}
+		if d.packSize == -1 {
+			// Not a pack of the enclosing template: the expansion is kept.
+			return t + "...", nil
+		}
+		if d.packSize > 0 {
+			elements = append(elements, t)
+		}
+		if d.packElement++; d.packElement >= d.packSize {
+			return strings.Join(elements, ", "), nil
+		}
+		d.pos, d.subs = start, d.subs[:subs]
+	}
+}
+
+// withArgs appends rendered template arguments to a name, keeping
+// operator< and operator<< apart from them.
+func withArgs(name, args string) string {
+	if strings.HasSuffix(name, "<") {
+		return name + " " + args
+	}
+	return name + args
+}
+
+// templateArgList parses template arguments and renders them; with top,
+// they become the arguments template parameters refer to.
+func (d *demangler) templateArgList(top bool) (string, error) {
+	d.pos++
+	var args []templateArg
+	var texts []string
+	for !d.consume("E") {
+		if d.pos >= len(d.input) {
+			return "", errUnsupported
+		}
+		arg, err := d.templateArg()
+		if err != nil {
+			return "", err
+		}
+		args = append(args, arg)
+		if arg.text != "" {
+			texts = append(texts, finish(arg.text))
+		}
+	}
+	if top {
+		d.templateArgs = args
+	}
+	// Like c++filt, keep closing angle brackets apart, a<b<c> >, unless an
+	// empty pack comes last.
+	rendered := strings.Join(texts, ", ")
+	if len(args) > 0 && strings.HasSuffix(args[len(args)-1].text, ">") {
+		rendered += " "
+	}
+	return "<" + rendered + ">", nil
+}
+
+func (d *demangler) templateArg() (templateArg, error) {
+	if d.peek(0) == 'J' {
+		d.pos++
+		pack := []string{}
+		for !d.consume("E") {
+			if d.pos >= len(d.input) {
+				return templateArg{}, errUnsupported
+			}
+			arg, err := d.templateArg()
+			if err != nil {
+				return templateArg{}, err
+			}
+			if arg.text != "" {
+				pack = append(pack, arg.text)
+			}
+		}
+		return templateArg{text: strings.Join(pack, ", "), pack: pack}, nil
+	}
+	text, err := d.templateValue()
+	return templateArg{text: text}, err
+}
+
+func (d *demangler) templateValue() (string, error) {
+	switch d.peek(0) {
+	case 'L':
+		d.pos++
+		kind := d.peek(0)
+		t, err := d.typ()
+		if err != nil {
+			return "", err
+		}
+		start := d.pos
+		for d.pos < len(d.input) && d.input[d.pos] != 'E' {
+			d.pos++
+		}
+		if !d.consume("E") {
+			return "", errUnsupported
+		}
+		value := strings.Replace(d.input[start:d.pos-1], "n", "-", 1)
+		switch kind {
+		case 'b':
+			return map[string]string{"0": "false", "1": "true"}[value], nil
+		case 'i':
+			return value, nil
+		case 'j', 'l', 'm', 'x', 'y':
+			return value + map[byte]string{'j': "u", 'l': "l", 'm': "ul", 'x': "ll", 'y': "ull"}[kind], nil
+		}
+		return "(" + t + ")" + value, nil
+	case 'X':
+		return "", errUnsupported
+	}
+	return d.typ()
+}
+
+// typ parses a type, adding it to the substitution candidates unless it is
+// a builtin type.
+func (d *demangler) typ() (string, error) {
+	c := d.peek(0)
+	if builtin, ok := builtinTypes[c]; ok {
+		d.pos++
+		return builtin, nil
+	}
+
+	var t string
+	switch c {
+	case 'D':
+		if builtin, ok := builtinDTypes[d.peek(1)]; ok {
+			d.pos += 2
+			return builtin, nil
+		}
+		if d.peek(1) != 'p' {
+			return "", errUnsupported
+		}
+		d.pos += 2
+		expansion, err := d.packExpansion()
+		if err != nil {
+			return "", err
+		}
+		t = expansion
+	case 'u':
+		d.pos++
+		return d.sourceName()
+	case 'P', 'R', 'O':
+		d.pos++
+		inner, err := d.typ()
+		if err != nil {
+			return "", err
+		}
+		t = declarator(inner, map[byte]string{'P': "*", 'R': "&", 'O': "&&"}[c])
+	case 'K', 'V', 'r':
+		qualifiers := ""
+		for {
+			if d.consume("r") {
+				qualifiers += " restrict"
+			} else if d.consume("V") {
+				qualifiers += " volatile"
+			} else if d.consume("K") {
+				qualifiers += " const"
+			} else {
+				break
+			}
+		}
+		inner, err := d.typ()
+		if err != nil {
+			return "", err
+		}
+		// Qualifiers of an array type apply to its elements, and a type
+		// already qualified is not qualified twice.
+		if element, bounds, ok := strings.Cut(inner, " (\x00) ["); ok {
+			t = element + qualifiers + " (\x00) [" + bounds
+		} else if strings.HasSuffix(inner, qualifiers) {
+			t = inner
+		} else {
+			t = inner + qualifiers
+		}
+	case 'F':
+		d.pos++
+		d.consume("Y")
+		result, err := d.typ()
+		if err != nil {
+			return "", err
+		}
+		var params []string
+		for d.peek(0) != 'E' {
+			if d.pos >= len(d.input) {
+				return "", errUnsupported
+			}
+			if (d.peek(0) == 'R' || d.peek(0) == 'O') && d.peek(1) == 'E' {
+				d.pos++
+				continue
+			}
+			param, err := d.typ()
+			if err != nil {
+				return "", err
+			}
+			params = append(params, param)
+		}
+		d.pos++
+		if len(params) == 1 && params[0] == "void" {
+			params = nil
+		}
+		t = result + " (\x00)(" + strings.Join(params, ", ") + ")"
+	case 'A':
+		d.pos++
+		// The bound is left out for arrays of unknown size, as in T[].
+		start := d.pos
+		for d.pos < len(d.input) && d.input[d.pos] >= '0' && d.input[d.pos] <= '9' {
+			d.pos++
+		}
+		bound := d.input[start:d.pos]
+		if !d.consume("_") {
+			return "", errUnsupported
+		}
+		element, err := d.typ()
+		if err != nil {
+			return "", err
+		}
+		t = element + " (\x00) [" + bound + "]"
+	case 'M':
+		d.pos++
+		class, err := d.typ()
+		if err != nil {
+			return "", err
+		}
+		member, err := d.typ()
+		if err != nil {
+			return "", err
+		}
+		t = declarator(member, class+"::*")
+	case 'T':
+		param, err := d.templateParam()
+		if err != nil {
+			return "", err
+		}
+		t = param
+		if d.peek(0) == 'I' {
+			d.subs = append(d.subs, t)
+			args, err := d.templateArgList(false)
+			if err != nil {
+				return "", err
+			}
+			t = withArgs(t, args)
+		}
+	case 'S':
+		if d.peek(1) != 't' {
+			sub, err := d.substitution()
+			if err != nil {
+				return "", err
+			}
+			if d.peek(0) != 'I' {
+				return sub, nil
+			}
+			args, err := d.templateArgList(false)
+			if err != nil {
+				return "", err
+			}
+			t = withArgs(sub, args)
+			break
+		}
+		fallthrough
+	default:
+		name, _, _, err := d.name(false)
+		if err != nil {
+			return "", err
+		}
+		t = name
+	}
+	d.subs = append(d.subs, t)
+	return t, nil
+}
+
+// declarator applies a pointer, reference or pointer-to-member declarator
+// to a type, inside the parentheses of a function type. References to
+// references collapse as in C++: only && applied to && stays &&.
+func declarator(t, operator string) string {
+	t, rest, nested := strings.Cut(t, "\x00")
+	if nested {
+		rest = "\x00" + rest
+	}
+	switch {
+	case strings.HasPrefix(operator, "&") && strings.HasSuffix(t, "&"):
+		if operator == "&" && strings.HasSuffix(t, "&&") {
+			t = t[:len(t)-1]
+		}
+		return t + rest
+	case nested || strings.HasPrefix(operator, "&") || strings.HasPrefix(operator, "*"):
+		return t + operator + rest
+	}
+	return t + " " + operator
+}
+
+// finish drops the declarator placeholders of function and array types.
+func finish(t string) string {
+	t = strings.ReplaceAll(t, "(\x00) ", "")
+	t = strings.ReplaceAll(t, "(\x00)", "")
+	return strings.ReplaceAll(t, "\x00", "")
+}
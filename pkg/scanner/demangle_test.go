@@ -0,0 +1,51 @@
+package scanner
+
+import "testing"
+
+// TestDemangleSymbol checks real NDK, libc++ and libstdc++ symbols against
+// the output of GNU c++filt. An empty want means the symbol keeps its mangled
+// form: c++filt rejects it, or it uses a construct the demangler leaves out.
+func TestDemangleSymbol(t *testing.T) {
+	tests := []struct {
+		symbol string
+		want   string
+	}{
+		{"_Z23Java_com_app_Root_checkP7_JNIEnvP8_jobject", "Java_com_app_Root_check(_JNIEnv*, _jobject*)"},
+		{"_ZN7_JNIEnv17GetStringUTFCharsEP8_jstringPh", "_JNIEnv::GetStringUTFChars(_jstring*, unsigned char*)"},
+		{"_ZN4Root5checkEv.cold", "Root::check() [clone .cold]"},
+		{"_ZNSt6__ndk112basic_stringIcNS_11char_traitsIcEENS_9allocatorIcEEED2Ev", "std::__ndk1::basic_string<char, std::__ndk1::char_traits<char>, std::__ndk1::allocator<char> >::~basic_string()"},
+		{"_ZNKSt6__ndk112basic_stringIcNS_11char_traitsIcEENS_9allocatorIcEEE4findEPKcmm", "std::__ndk1::basic_string<char, std::__ndk1::char_traits<char>, std::__ndk1::allocator<char> >::find(char const*, unsigned long, unsigned long) const"},
+		{"_ZNSt6__ndk16vectorINS_12basic_stringIcNS_11char_traitsIcEENS_9allocatorIcEEEENS4_IS6_EEE21__push_back_slow_pathIRKS6_EEvOT_", "void std::__ndk1::vector<std::__ndk1::basic_string<char, std::__ndk1::char_traits<char>, std::__ndk1::allocator<char> >, std::__ndk1::allocator<std::__ndk1::basic_string<char, std::__ndk1::char_traits<char>, std::__ndk1::allocator<char> > > >::__push_back_slow_path<std::__ndk1::basic_string<char, std::__ndk1::char_traits<char>, std::__ndk1::allocator<char> > const&>(std::__ndk1::basic_string<char, std::__ndk1::char_traits<char>, std::__ndk1::allocator<char> > const&)"},
+		{"_ZNSt6__ndk113basic_ostreamIcNS_11char_traitsIcEEElsEi", "std::__ndk1::basic_ostream<char, std::__ndk1::char_traits<char> >::operator<<(int)"},
+		{"_ZNSt6__ndk14endlIcNS_11char_traitsIcEEEERNS_13basic_ostreamIT_T0_EES7_", "std::__ndk1::basic_ostream<char, std::__ndk1::char_traits<char> >& std::__ndk1::endl<char, std::__ndk1::char_traits<char> >(std::__ndk1::basic_ostream<char, std::__ndk1::char_traits<char> >&)"},
+		{"_ZTVNSt6__ndk120__shared_ptr_emplaceI4RootNS_9allocatorIS1_EEEE", "vtable for std::__ndk1::__shared_ptr_emplace<Root, std::__ndk1::allocator<Root> >"},
+		{"_ZNSt6__ndk110unique_ptrIA_cNS_14default_deleteIS1_EEED2Ev", "std::__ndk1::unique_ptr<char [], std::__ndk1::default_delete<char []> >::~unique_ptr()"},
+		{"_ZNSt6__ndk18functionIFbvEEC2EOS2_", "std::__ndk1::function<bool ()>::function(std::__ndk1::function<bool ()>&&)"},
+		{"_ZNSt6__ndk110shared_ptrI4RootE18__enable_weak_thisB7v160006IS1_S1_EEvPKNS_23enable_shared_from_thisIT_EEPT0_", "void std::__ndk1::shared_ptr<Root>::__enable_weak_this[abi:v160006]<Root, Root>(std::__ndk1::enable_shared_from_this<Root> const*, Root*)"},
+		{"_ZNKSt6__ndk16vectorIiNS_9allocatorIiEEE4sizeB7v160006Ev", "std::__ndk1::vector<int, std::__ndk1::allocator<int> >::size[abi:v160006]() const"},
+		{"_ZNSt6__ndk112basic_stringIcNS_11char_traitsIcEENS_9allocatorIcEEEC2B7v160006IDnEEPKc", ""}, // rejected by c++filt
+		{"_ZNSt3__112basic_stringIcNS_11char_traitsIcEENS_9allocatorIcEEEC2B7v160006IDnEEPKc", ""},    // rejected by c++filt
+		{"_ZNSt6__ndk15tupleIJRKiEEC2B7v160006IJS2_EEEDpOT_", ""}, // rejected by c++filt
+		{"_ZGVNSt7num_getIcSt19istreambuf_iteratorIcSt11char_traitsIcEEE2idE", "guard variable for std::num_get<char, std::istreambuf_iterator<char, std::char_traits<char> > >::id"},
+		{"_ZN22NewPMCheckDebugifyPass3runERN4llvm6ModuleERNS0_15AnalysisManagerIS1_JEEE", "NewPMCheckDebugifyPass::run(llvm::Module&, llvm::AnalysisManager<llvm::Module>&)"},
+		{"_ZN4llvm11PassManagerINS_6ModuleENS_15AnalysisManagerIS1_JEEEJEE10isRequiredEv", "llvm::PassManager<llvm::Module, llvm::AnalysisManager<llvm::Module>>::isRequired()"},
+		{"_ZN4llvm10make_errorINS_11StringErrorEJRA19_KcSt10error_codeEEENS_5ErrorEDpOT0_", "llvm::Error llvm::make_error<llvm::StringError, char const (&) [19], std::error_code>(char const (&) [19], std::error_code&&)"},
+		{"_ZN4llvm2cl5applyINS0_3optIbLb0ENS0_6parserIbEEEEA14_cJNS0_4descENS0_12OptionHiddenENS0_11initializerIbEENS0_3catENS0_3subEEEEvPT_RKT0_DpRKT1_", "void llvm::cl::apply<llvm::cl::opt<bool, false, llvm::cl::parser<bool> >, char [14], llvm::cl::desc, llvm::cl::OptionHidden, llvm::cl::initializer<bool>, llvm::cl::cat, llvm::cl::sub>(llvm::cl::opt<bool, false, llvm::cl::parser<bool> >*, char const (&) [14], llvm::cl::desc const&, llvm::cl::OptionHidden const&, llvm::cl::initializer<bool> const&, llvm::cl::cat const&, llvm::cl::sub const&)"},
+		{"_ZN4llvm10Attributor32registerFunctionSignatureRewriteERNS_8ArgumentENS_8ArrayRefIPNS_4TypeEEEOSt8functionIFvRKNS0_23ArgumentReplacementInfoERNS_8FunctionEPS1_EEOS7_IFvSA_NS_16AbstractCallSiteERNS_15SmallVectorImplIPNS_5ValueEEEEE", "llvm::Attributor::registerFunctionSignatureRewrite(llvm::Argument&, llvm::ArrayRef<llvm::Type*>, std::function<void (llvm::Attributor::ArgumentReplacementInfo const&, llvm::Function&, llvm::Argument*)>&&, std::function<void (llvm::Attributor::ArgumentReplacementInfo const&, llvm::AbstractCallSite, llvm::SmallVectorImpl<llvm::Value*>&)>&&)"},
+		{"_ZNKSs4findERKSsm", "std::basic_string<char, std::char_traits<char>, std::allocator<char> >::find(std::basic_string<char, std::char_traits<char>, std::allocator<char> > const&, unsigned long) const"},
+		{"_ZNSsC1Ev", "std::basic_string<char, std::char_traits<char>, std::allocator<char> >::basic_string()"},
+		{"_ZNKSt8messagesIcE4openERKSsRKSt6locale", "std::messages<char>::open(std::basic_string<char, std::char_traits<char>, std::allocator<char> > const&, std::locale const&) const"},
+		{"_ZGVZN4Root5checkEvE5cache", ""}, // local names and thunks are not supported
+		{"_ZThn8_N4Root5checkEv", ""},      // local names and thunks are not supported
+		{"_Z", ""},
+		{"_ZN4Root", ""},
+		{"Java_com_app_Root_check", ""},
+	}
+
+	for _, test := range tests {
+		got, ok := demangleSymbol(test.symbol)
+		if ok != (test.want != "") || got != test.want {
+			t.Errorf("demangleSymbol(%q) = %q, %v, want %q", test.symbol, got, ok, test.want)
+		}
+	}
+}
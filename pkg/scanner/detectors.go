@@ -0,0 +1,130 @@
+package scanner
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+type StructuralDetector struct {
+	ID          string
+	Name        string
+	Description string
+	TargetLabel string
+	Weight      int
+	Detect      func(methodContent string) ([]string, bool)
+	// Fingerprint identifies what Detect matches when it is built at run
+	// time, such as the conditions of a rule, so results cached under a
+	// different definition are not reused.
+	Fingerprint string
+}
+
+// DefaultDetectors returns the built-in structural detectors, followed by
+// the built-in rules.
+func DefaultDetectors() []StructuralDetector {
+	rules, err := RuleDetectors(DefaultRules())
+	if err != nil {
+		panic(err)
+	}
+	return append([]StructuralDetector{
+		{
+			ID:          "package_enum",
+			Name:        "Installed Package Enumeration",
+			Description: "enumerating installed packages for root/hooking apps",
+			TargetLabel: "Packages checked",
+			Weight:      3,
+			Detect:      detectInstalledPackageEnumeration,
+		},
+		{
+			ID:          "reflection",
+			Name:        "Reflective API Access",
+			Description: "reaching detection-relevant APIs through reflection",
+			TargetLabel: "Reflected targets",
+			Weight:      2,
+			Detect:      detectReflectiveAPIAccess,
+		},
+	}, rules...)
+}
+
+var constStringPattern = regexp.MustCompile(`const-string(?:/jumbo)? [vp]\d+, "((?:[^"\\]|\\.)*)"`)
+
+var hookingManagerPackages = []string{"org.lsposed.manager", "io.github.lsposed.manager", "org.meowcat.edxposed.manager", "com.solohsu.android.edxp.manager", "io.va.exposed", "me.weishu.exp", "io.github.vvb2060.magisk", "io.github.huskydg.magisk", "me.bmax.apatch", "com.android.shell.su"}
+
+var reflectionTargets = []string{"android.os.SystemProperties", "android.os.Debug", "isDebuggerConnected", "android.os.Build", "java.lang.Runtime", "java.lang.ProcessBuilder", "android.app.ActivityThread", "currentApplication", "android.content.pm.PackageManager", "getInstalledPackages", "getInstalledApplications", "getPackageInfo", "java.security.MessageDigest", "android.content.pm.Signature", "de.robv.android.xposed.XposedBridge", "de.robv.android.xposed.XposedHelpers", "com.saurik.substrate.MS", "dalvik.system.VMDebug", "isDebuggingEnabled", "ro.debuggable", "ro.secure", "ro.build.tags", "ro.kernel.qemu", "ro.hardware", "ro.product.model"}
+
+// ConstStrings returns the string literals loaded by const-string
+// instructions in smali code.
+func ConstStrings(methodContent string) []string {
+	var literals []string
+	for _, match := range constStringPattern.FindAllStringSubmatch(methodContent, -1) {
+		literals = append(literals, match[1])
+	}
+	return literals
+}
+
+func isPackageName(keyword string) bool {
+	if strings.ContainsAny(keyword, "/ ") || strings.HasSuffix(keyword, ".apk") || strings.Count(keyword, ".") < 2 {
+		return false
+	}
+	prefix := keyword[:strings.Index(keyword, ".")]
+	return prefix != "ro" && prefix != "service" && prefix != ""
+}
+
+func knownRootAndHookPackages() map[string]struct{} {
+	packages := make(map[string]struct{})
+	for _, keyword := range root_detection_keywords {
+		if isPackageName(keyword) {
+			packages[keyword] = struct{}{}
+		}
+	}
+	for _, pkg := range hookingManagerPackages {
+		packages[pkg] = struct{}{}
+	}
+	return packages
+}
+
+func detectInstalledPackageEnumeration(methodContent string) ([]string, bool) {
+	if !strings.Contains(methodContent, "->getInstalledPackages(") && !strings.Contains(methodContent, "->getInstalledApplications(") {
+		return nil, false
+	}
+
+	packages := knownRootAndHookPackages()
+	var targets []string
+	seen := make(map[string]struct{})
+	for _, literal := range ConstStrings(methodContent) {
+		if _, known := packages[literal]; !known {
+			continue
+		}
+		if _, dup := seen[literal]; dup {
+			continue
+		}
+		seen[literal] = struct{}{}
+		targets = append(targets, literal)
+	}
+
+	return targets, len(targets) > 0
+}
+
+func detectReflectiveAPIAccess(methodContent string) ([]string, bool) {
+	if !strings.Contains(methodContent, "Ljava/lang/Class;->forName(") && !strings.Contains(methodContent, "Ljava/lang/Class;->getMethod(") && !strings.Contains(methodContent, "Ljava/lang/Class;->getDeclaredMethod(") && !strings.Contains(methodContent, "Ljava/lang/reflect/Method;->invoke(") {
+		return nil, false
+	}
+
+	var targets []string
+	for _, literal := range ConstStrings(methodContent) {
+		if slices.Contains(reflectionTargets, literal) && !slices.Contains(targets, literal) {
+			targets = append(targets, literal)
+		}
+	}
+	return targets, len(targets) > 0
+}
+
+func runStructuralDetectors(detectors []StructuralDetector, methodContent string) []Detection {
+	var detections []Detection
+	for _, detector := range detectors {
+		if targets, found := detector.Detect(methodContent); found {
+			detections = append(detections, Detection{Detector: detector.Name, Weight: detector.Weight, Targets: targets})
+		}
+	}
+	return detections
+}
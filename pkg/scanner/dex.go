@@ -0,0 +1,413 @@
+package scanner
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+const dexNoIndex = 0xffffffff
+
+var dexEntryPattern = regexp.MustCompile(`^classes(\d*)\.dex$`)
+
+// dexMagicPattern matches the 8-byte magic a DEX file starts with, such as
+// dex\n035\0.
+var dexMagicPattern = regexp.MustCompile(`^dex\n\d{3}\x00$`)
+
+// dexFile is a minimal reader for the parts of the DEX format boolseeker
+// needs: class definitions, method signatures and the string, type, field
+// and method references made by each method's bytecode.
+type dexFile struct {
+	data []byte
+}
+
+func (d *dexFile) u16(off uint32) uint32 {
+	return uint32(binary.LittleEndian.Uint16(d.data[off : off+2]))
+}
+
+func (d *dexFile) u32(off uint32) uint32 {
+	return binary.LittleEndian.Uint32(d.data[off : off+4])
+}
+
+func (d *dexFile) uleb128(off *uint32) uint32 {
+	var result uint32
+	for shift := 0; shift < 35; shift += 7 {
+		b := d.data[*off]
+		*off++
+		result |= uint32(b&0x7f) << shift
+		if b&0x80 == 0 {
+			break
+		}
+	}
+	return result
+}
+
+// str decodes the MUTF-8 string with the given string_ids index.
+func (d *dexFile) str(idx uint32) string {
+	off := d.u32(d.u32(0x3c) + idx*4)
+	d.uleb128(&off)
+
+	var units []uint16
+	for {
+		b := d.data[off]
+		switch {
+		case b == 0:
+			return string(utf16.Decode(units))
+		case b < 0x80:
+			units = append(units, uint16(b))
+			off++
+		case b&0xe0 == 0xc0:
+			units = append(units, uint16(b&0x1f)<<6|uint16(d.data[off+1]&0x3f))
+			off += 2
+		default:
+			units = append(units, uint16(b&0x0f)<<12|uint16(d.data[off+1]&0x3f)<<6|uint16(d.data[off+2]&0x3f))
+			off += 3
+		}
+	}
+}
+
+func (d *dexFile) typeName(idx uint32) string {
+	return d.str(d.u32(d.u32(0x44) + idx*4))
+}
+
+func (d *dexFile) protoDescriptor(idx uint32) string {
+	proto := d.u32(0x4c) + idx*12
+	var params strings.Builder
+	if paramsOff := d.u32(proto + 8); paramsOff != 0 {
+		for i := uint32(0); i < d.u32(paramsOff); i++ {
+			params.WriteString(d.typeName(d.u16(paramsOff + 4 + i*2)))
+		}
+	}
+	return "(" + params.String() + ")" + d.typeName(d.u32(proto+4))
+}
+
+func (d *dexFile) methodRef(idx uint32) (class, name, descriptor string) {
+	method := d.u32(0x5c) + idx*8
+	return d.typeName(d.u16(method)), d.str(d.u32(method + 4)), d.protoDescriptor(d.u16(method + 2))
+}
+
+func (d *dexFile) fieldRef(idx uint32) string {
+	field := d.u32(0x54) + idx*8
+	return d.typeName(d.u16(field)) + "->" + d.str(d.u32(field+4)) + ":" + d.typeName(d.u16(field+2))
+}
+
+var dexAccessFlags = []struct {
+	flag uint32
+	name string
+}{
+	{0x1, "public"}, {0x2, "private"}, {0x4, "protected"}, {0x8, "static"}, {0x10, "final"},
+	{0x20, "synchronized"}, {0x100, "native"}, {0x400, "abstract"}, {0x10000, "constructor"},
+}
+
+func dexAccessString(flags uint32) string {
+	var names []string
+	for _, access := range dexAccessFlags {
+		if flags&access.flag != 0 {
+			names = append(names, access.name)
+		}
+	}
+	return strings.Join(names, " ")
+}
+
+var (
+	dexInvokeNames = []string{"invoke-virtual", "invoke-super", "invoke-direct", "invoke-static", "invoke-interface"}
+	dexFieldNames  = []string{"", "-wide", "-object", "-boolean", "-byte", "-char", "-short"}
+)
+
+// dexInstructionUnits holds the size in 16-bit code units of each opcode.
+var dexInstructionUnits = func() [256]uint32 {
+	var units [256]uint32
+	set := func(from, to int, size uint32) {
+		for op := from; op <= to; op++ {
+			units[op] = size
+		}
+	}
+	set(0x00, 0xff, 1)
+	set(0x02, 0x02, 2)
+	set(0x03, 0x03, 3)
+	set(0x05, 0x05, 2)
+	set(0x06, 0x06, 3)
+	set(0x08, 0x08, 2)
+	set(0x09, 0x09, 3)
+	set(0x13, 0x13, 2)
+	set(0x14, 0x14, 3)
+	set(0x15, 0x16, 2)
+	set(0x17, 0x17, 3)
+	set(0x18, 0x18, 5)
+	set(0x19, 0x1a, 2)
+	set(0x1b, 0x1b, 3)
+	set(0x1c, 0x1c, 2)
+	set(0x1f, 0x20, 2)
+	set(0x22, 0x23, 2)
+	set(0x24, 0x26, 3)
+	set(0x29, 0x29, 2)
+	set(0x2a, 0x2c, 3)
+	set(0x2d, 0x3d, 2)
+	set(0x44, 0x6d, 2)
+	set(0x6e, 0x72, 3)
+	set(0x74, 0x78, 3)
+	set(0x90, 0xaf, 2)
+	set(0xd0, 0xe2, 2)
+	set(0xfa, 0xfb, 4)
+	set(0xfc, 0xfd, 3)
+	set(0xfe, 0xff, 2)
+	return units
+}()
+
+// writeMethodBody writes the references and constants of the bytecode at
+// codeOff as smali-like lines, enough for keyword matching, the structural
+// detectors, string reassembly and the call graph to work on the output as
+// they do on apktool's.
+func (d *dexFile) writeMethodBody(w io.Writer, codeOff uint32) {
+	size := d.u32(codeOff + 12)
+	insns := codeOff + 16
+	unit := func(pc uint32) uint32 { return d.u16(insns + pc*2) }
+
+	for pc := uint32(0); pc < size; {
+		op := unit(pc) & 0xff
+		switch {
+		case op == 0x00 && unit(pc) == 0x0100:
+			pc += 4 + unit(pc+1)*2
+			continue
+		case op == 0x00 && unit(pc) == 0x0200:
+			pc += 2 + unit(pc+1)*4
+			continue
+		case op == 0x00 && unit(pc) == 0x0300:
+			elements := unit(pc+2) | unit(pc+3)<<16
+			pc += 4 + (elements*unit(pc+1)+1)/2
+			continue
+		case op == 0x12:
+			fmt.Fprintf(w, "    const/4 v%d, %s\n", unit(pc)>>8&0xf, smaliHex(int64(int8(unit(pc)>>8)>>4)))
+		case op == 0x13:
+			fmt.Fprintf(w, "    const/16 v%d, %s\n", unit(pc)>>8, smaliHex(int64(int16(unit(pc+1)))))
+		case op == 0x14:
+			fmt.Fprintf(w, "    const v%d, %s\n", unit(pc)>>8, smaliHex(int64(int32(unit(pc+1)|unit(pc+2)<<16))))
+		case op == 0x15:
+			fmt.Fprintf(w, "    const/high16 v%d, %s\n", unit(pc)>>8, smaliHex(int64(int32(unit(pc+1)<<16))))
+		case op == 0x1a:
+			fmt.Fprintf(w, "    const-string v0, %s\n", strconv.Quote(d.str(unit(pc+1))))
+		case op == 0x1b:
+			fmt.Fprintf(w, "    const-string/jumbo v0, %s\n", strconv.Quote(d.str(unit(pc+1)|unit(pc+2)<<16)))
+		case op == 0x1c:
+			fmt.Fprintf(w, "    const-class v0, %s\n", d.typeName(unit(pc+1)))
+		case op == 0x1f:
+			fmt.Fprintf(w, "    check-cast v0, %s\n", d.typeName(unit(pc+1)))
+		case op == 0x20:
+			fmt.Fprintf(w, "    instance-of v0, v0, %s\n", d.typeName(unit(pc+1)))
+		case op == 0x22:
+			fmt.Fprintf(w, "    new-instance v0, %s\n", d.typeName(unit(pc+1)))
+		case op == 0x50:
+			fmt.Fprintf(w, "    aput-char v%d, v%d, v%d\n", unit(pc)>>8, unit(pc+1)&0xff, unit(pc+1)>>8)
+		case op >= 0x52 && op <= 0x5f:
+			kind := "iget"
+			if op >= 0x59 {
+				kind = "iput"
+			}
+			fmt.Fprintf(w, "    %s%s v0, v0, %s\n", kind, dexFieldNames[(op-0x52)%7], d.fieldRef(unit(pc+1)))
+		case op >= 0x60 && op <= 0x6d:
+			kind := "sget"
+			if op >= 0x67 {
+				kind = "sput"
+			}
+			fmt.Fprintf(w, "    %s%s v0, %s\n", kind, dexFieldNames[(op-0x60)%7], d.fieldRef(unit(pc+1)))
+		case op >= 0x6e && op <= 0x72, op >= 0x74 && op <= 0x78:
+			var name, registers string
+			if op >= 0x74 {
+				name = dexInvokeNames[op-0x74] + "/range"
+				first, count := unit(pc+2), unit(pc)>>8
+				switch count {
+				case 0:
+				case 1:
+					registers = fmt.Sprintf("v%d", first)
+				default:
+					registers = fmt.Sprintf("v%d .. v%d", first, first+count-1)
+				}
+			} else {
+				name = dexInvokeNames[op-0x6e]
+				args := unit(pc+2) | (unit(pc)>>8&0xf)<<16
+				list := make([]string, unit(pc)>>12)
+				for i := range list {
+					list[i] = fmt.Sprintf("v%d", args>>(4*i)&0xf)
+				}
+				registers = strings.Join(list, ", ")
+			}
+			class, method, descriptor := d.methodRef(unit(pc + 1))
+			fmt.Fprintf(w, "    %s {%s}, %s->%s%s\n", name, registers, class, method, descriptor)
+		}
+		pc += dexInstructionUnits[op]
+	}
+}
+
+// smaliHex formats a literal the way smali does, as signed hex.
+func smaliHex(value int64) string {
+	if value < 0 {
+		return fmt.Sprintf("-0x%x", -value)
+	}
+	return fmt.Sprintf("0x%x", value)
+}
+
+// writeSmali writes every class of the DEX file as a stub smali file below
+// directory, laid out the way apktool lays out its output.
+func (d *dexFile) writeSmali(directory string) error {
+	classDefs := d.u32(0x64)
+	for i := uint32(0); i < d.u32(0x60); i++ {
+		classDef := classDefs + i*32
+		descriptor := d.typeName(d.u32(classDef))
+		if !strings.HasPrefix(descriptor, "L") || !strings.HasSuffix(descriptor, ";") {
+			continue
+		}
+		path := filepath.Join(directory, filepath.FromSlash(descriptor[1:len(descriptor)-1])+".smali")
+		if !strings.HasPrefix(path, filepath.Clean(directory)+string(filepath.Separator)) {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		w := bufio.NewWriter(file)
+
+		fmt.Fprintf(w, ".class %s %s\n", dexAccessString(d.u32(classDef+4)), descriptor)
+		if super := d.u32(classDef + 8); super != dexNoIndex {
+			fmt.Fprintf(w, ".super %s\n", d.typeName(super))
+		}
+
+		if off := d.u32(classDef + 24); off != 0 {
+			staticFields, instanceFields := d.uleb128(&off), d.uleb128(&off)
+			directMethods, virtualMethods := d.uleb128(&off), d.uleb128(&off)
+			for j := uint32(0); j < (staticFields+instanceFields)*2; j++ {
+				d.uleb128(&off)
+			}
+			for _, count := range []uint32{directMethods, virtualMethods} {
+				var methodIdx uint32
+				for j := uint32(0); j < count; j++ {
+					methodIdx += d.uleb128(&off)
+					access, codeOff := d.uleb128(&off), d.uleb128(&off)
+					_, name, signature := d.methodRef(methodIdx)
+
+					fmt.Fprintf(w, "\n.method %s %s%s\n", dexAccessString(access), name, signature)
+					if codeOff != 0 {
+						d.writeMethodBody(w, codeOff)
+					}
+					fmt.Fprintln(w, ".end method")
+				}
+			}
+		}
+
+		if err := w.Flush(); err != nil {
+			file.Close()
+			return err
+		}
+		if err := file.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeDexSmali(data []byte, directory string) (err error) {
+	if len(data) < 0x70 || string(data[:4]) != "dex\n" {
+		return fmt.Errorf("not a DEX file")
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed DEX file: %v", r)
+		}
+	}()
+	return (&dexFile{data: data}).writeSmali(directory)
+}
+
+// IsRawDex reports whether path is a DEX file rather than an APK, such as a
+// classes.dex extracted by hand or dumped from the memory of a packed app.
+func IsRawDex(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	magic := make([]byte, 8)
+	if _, err := io.ReadFull(file, magic); err != nil {
+		return false
+	}
+	return dexMagicPattern.Match(magic)
+}
+
+// DecodeRawDex writes stub smali for a DEX file given on its own to the
+// smali directory of outputDirectory, as DecodeDex does for the classes.dex
+// of an APK. There is no manifest, resources or native code to extract.
+func DecodeRawDex(dexFile, outputDirectory string) error {
+	data, err := os.ReadFile(dexFile)
+	if err != nil {
+		return fmt.Errorf("could not read DEX file: %w", err)
+	}
+	if err := writeDexSmali(data, filepath.Join(outputDirectory, "smali")); err != nil {
+		return fmt.Errorf("could not parse %s: %w", dexFile, err)
+	}
+	return nil
+}
+
+// DecodeDex decodes an APK without apktool: it extracts the APK into
+// outputDirectory and writes stub smali for every classes*.dex, holding the
+// method signatures and the strings, fields and methods they reference.
+func DecodeDex(apkFile, outputDirectory string) error {
+	zipReader, err := zip.OpenReader(apkFile)
+	if err != nil {
+		return fmt.Errorf("could not read APK: %w", err)
+	}
+	defer zipReader.Close()
+
+	root := filepath.Clean(outputDirectory) + string(filepath.Separator)
+	for _, file := range zipReader.File {
+		if strings.HasSuffix(file.Name, "/") {
+			continue
+		}
+
+		data, err := readZipFile(file)
+		if err != nil {
+			return fmt.Errorf("could not read %s: %w", file.Name, err)
+		}
+
+		if match := dexEntryPattern.FindStringSubmatch(file.Name); match != nil {
+			smaliDir := "smali"
+			if match[1] != "" {
+				smaliDir = "smali_classes" + match[1]
+			}
+			if err := writeDexSmali(data, filepath.Join(outputDirectory, smaliDir)); err != nil {
+				return fmt.Errorf("could not parse %s: %w", file.Name, err)
+			}
+			continue
+		}
+
+		path := filepath.Join(outputDirectory, filepath.FromSlash(file.Name))
+		if !strings.HasPrefix(path, root) {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readZipFile(file *zip.File) ([]byte, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
@@ -0,0 +1,261 @@
+package scanner
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// testDexMethod is a method of a class in the DEX built by buildTestDex, with
+// the bytecode of its body.
+type testDexMethod struct {
+	class, name, returnType string
+	params                  []string
+	access                  uint32
+	code                    []uint16
+}
+
+type testDexClass struct {
+	descriptor, super string
+	direct, virtual   []testDexMethod
+}
+
+// buildTestDex lays out a DEX file holding classes and the methods their
+// bytecode references: the id sections after the header, then string data,
+// type lists, code items and class data. The given strings, types and
+// references come first in their id sections, in order, so the bytecode can
+// refer to them by index. Shorty descriptors, which are not read, are all
+// the same placeholder.
+func buildTestDex(strs, types []string, references []testDexMethod, classes []testDexClass) []byte {
+	strs, types = slices.Clone(strs), slices.Clone(types)
+	var protos [][]string
+	var methods []testDexMethod
+	index := func(list *[]string, value string) uint32 {
+		if i := slices.Index(*list, value); i >= 0 {
+			return uint32(i)
+		}
+		*list = append(*list, value)
+		return uint32(len(*list) - 1)
+	}
+	typeIndex := func(descriptor string) uint32 {
+		index(&strs, descriptor)
+		return index(&types, descriptor)
+	}
+	protoIndex := func(method testDexMethod) uint32 {
+		proto := append([]string{method.returnType}, method.params...)
+		for _, t := range proto {
+			typeIndex(t)
+		}
+		index(&strs, "shorty")
+		if i := slices.IndexFunc(protos, func(p []string) bool { return slices.Equal(p, proto) }); i >= 0 {
+			return uint32(i)
+		}
+		protos = append(protos, proto)
+		return uint32(len(protos) - 1)
+	}
+	methodIndex := func(method testDexMethod) uint32 {
+		typeIndex(method.class)
+		index(&strs, method.name)
+		protoIndex(method)
+		for i, m := range methods {
+			if m.class == method.class && m.name == method.name && slices.Equal(m.params, method.params) {
+				return uint32(i)
+			}
+		}
+		methods = append(methods, method)
+		return uint32(len(methods) - 1)
+	}
+	for _, t := range types {
+		index(&strs, t)
+	}
+	for _, method := range references {
+		methodIndex(method)
+	}
+	for _, class := range classes {
+		typeIndex(class.descriptor)
+		typeIndex(class.super)
+		for _, method := range append(slices.Clone(class.direct), class.virtual...) {
+			methodIndex(method)
+		}
+	}
+
+	u16 := binary.LittleEndian.AppendUint16
+	u32 := binary.LittleEndian.AppendUint32
+	uleb128 := func(b []byte, v uint32) []byte {
+		for v >= 0x80 {
+			b = append(b, byte(v)|0x80)
+			v >>= 7
+		}
+		return append(b, byte(v))
+	}
+
+	idsSize := 4*len(strs) + 4*len(types) + 12*len(protos) + 8*len(methods) + 32*len(classes)
+	base := uint32(0x70 + idsSize)
+	var data []byte
+	place := func(b []byte, align int) uint32 {
+		for len(data)%align != 0 {
+			data = append(data, 0)
+		}
+		off := base + uint32(len(data))
+		data = append(data, b...)
+		return off
+	}
+
+	var stringOffs, typeListOffs []uint32
+	for _, s := range strs {
+		stringOffs = append(stringOffs, place(append(uleb128(nil, uint32(len(s))), s+"\x00"...), 1))
+	}
+	for _, proto := range protos {
+		var off uint32
+		if params := proto[1:]; len(params) > 0 {
+			list := u32(nil, uint32(len(params)))
+			for _, param := range params {
+				list = u16(list, uint16(typeIndex(param)))
+			}
+			off = place(list, 4)
+		}
+		typeListOffs = append(typeListOffs, off)
+	}
+	var classDataOffs []uint32
+	for _, class := range classes {
+		if len(class.direct)+len(class.virtual) == 0 {
+			classDataOffs = append(classDataOffs, 0)
+			continue
+		}
+		classData := uleb128(uleb128(uleb128(uleb128(nil, 0), 0), uint32(len(class.direct))), uint32(len(class.virtual)))
+		for _, list := range [][]testDexMethod{class.direct, class.virtual} {
+			previous := uint32(0)
+			for _, method := range list {
+				var codeOff uint32
+				if method.code != nil {
+					item := u32(u16(u16(u16(u16(nil, 4), 0), 0), 0), 0)
+					item = u32(item, uint32(len(method.code)))
+					for _, unit := range method.code {
+						item = u16(item, unit)
+					}
+					codeOff = place(item, 4)
+				}
+				idx := methodIndex(method)
+				classData = uleb128(uleb128(uleb128(classData, idx-previous), method.access), codeOff)
+				previous = idx
+			}
+		}
+		classDataOffs = append(classDataOffs, place(classData, 1))
+	}
+
+	header := make([]byte, 0x70)
+	copy(header, "dex\n035\x00")
+	dex := header
+	sections := []struct{ count, off uint32 }{}
+	section := func(count int) {
+		sections = append(sections, struct{ count, off uint32 }{uint32(count), uint32(len(dex))})
+	}
+	section(len(strs))
+	for _, off := range stringOffs {
+		dex = u32(dex, off)
+	}
+	section(len(types))
+	for _, t := range types {
+		dex = u32(dex, index(&strs, t))
+	}
+	section(len(protos))
+	for i, proto := range protos {
+		dex = u32(u32(u32(dex, index(&strs, "shorty")), typeIndex(proto[0])), typeListOffs[i])
+	}
+	section(0)
+	section(len(methods))
+	for _, method := range methods {
+		dex = u32(u16(u16(dex, uint16(typeIndex(method.class))), uint16(protoIndex(method))), index(&strs, method.name))
+	}
+	section(len(classes))
+	for i, class := range classes {
+		dex = u32(u32(dex, typeIndex(class.descriptor)), 0x1)
+		dex = u32(u32(u32(dex, typeIndex(class.super)), 0), dexNoIndex)
+		dex = u32(u32(u32(dex, 0), classDataOffs[i]), 0)
+	}
+	for i, s := range sections {
+		binary.LittleEndian.PutUint32(dex[0x38+i*8:], s.count)
+		binary.LittleEndian.PutUint32(dex[0x3c+i*8:], s.off)
+	}
+	return append(dex, data...)
+}
+
+func TestWriteDexSmali(t *testing.T) {
+	objectInit := testDexMethod{class: "Ljava/lang/Object;", name: "<init>", returnType: "V"}
+	fileInit := testDexMethod{class: "Ljava/io/File;", name: "<init>", returnType: "V", params: []string{"Ljava/lang/String;"}}
+	fileExists := testDexMethod{class: "Ljava/io/File;", name: "exists", returnType: "Z"}
+	format := testDexMethod{class: "Ljava/lang/String;", name: "format", returnType: "Ljava/lang/String;", params: []string{"Ljava/lang/String;", "[Ljava/lang/Object;"}}
+
+	root := "Lcom/app/Root;"
+	classes := []testDexClass{
+		{
+			descriptor: root,
+			super:      "Ljava/lang/Object;",
+			direct: []testDexMethod{
+				// invoke-direct {v0}, Object-><init>; return-void
+				{class: root, name: "<init>", returnType: "V", access: 0x10001, code: []uint16{0x1070, 0, 0x0000, 0x000e}},
+			},
+			virtual: []testDexMethod{
+				// const-string v0, "/system/xbin/su"; new-instance v1, File;
+				// invoke-direct {v1, v0}, File-><init>; invoke-virtual {v1},
+				// File->exists; move-result v0; return v0
+				{class: root, name: "isRooted", returnType: "Z", access: 0x1, code: []uint16{
+					0x001a, 0, 0x0122, 0, 0x2070, 1, 0x0001, 0x106e, 2, 0x0001, 0x000a, 0x000f,
+				}},
+				// const/4 v0, 0x1; const/16 v1, 0x73; invoke-static/range
+				// {v2 .. v3}, String->format; move-result-object v0; return-object v0
+				{class: root, name: "describe", returnType: "Ljava/lang/String;", params: []string{"I"}, access: 0x1, code: []uint16{
+					0x1012, 0x0113, 0x0073, 0x0277, 3, 0x0002, 0x000c, 0x0011,
+				}},
+				{class: root, name: "check", returnType: "V", access: 0x401},
+			},
+		},
+		{descriptor: "Lcom/app/Util;", super: "Ljava/lang/Object;"},
+	}
+	data := buildTestDex([]string{"/system/xbin/su"}, []string{"Ljava/io/File;"}, []testDexMethod{objectInit, fileInit, fileExists, format}, classes)
+	directory := t.TempDir()
+	if err := writeDexSmali(data, directory); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(directory, "com", "app", "Root.smali"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `.class public Lcom/app/Root;
+.super Ljava/lang/Object;
+
+.method public constructor <init>()V
+    invoke-direct {v0}, Ljava/lang/Object;-><init>()V
+.end method
+
+.method public isRooted()Z
+    const-string v0, "/system/xbin/su"
+    new-instance v0, Ljava/io/File;
+    invoke-direct {v1, v0}, Ljava/io/File;-><init>(Ljava/lang/String;)V
+    invoke-virtual {v1}, Ljava/io/File;->exists()Z
+.end method
+
+.method public describe(I)Ljava/lang/String;
+    const/4 v0, 0x1
+    const/16 v1, 0x73
+    invoke-static/range {v2 .. v3}, Ljava/lang/String;->format(Ljava/lang/String;[Ljava/lang/Object;)Ljava/lang/String;
+.end method
+
+.method public abstract check()V
+.end method
+`
+	if string(got) != want {
+		t.Errorf("Root.smali =\n%s\nwant\n%s", got, want)
+	}
+
+	util, err := os.ReadFile(filepath.Join(directory, "com", "app", "Util.smali"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := ".class public Lcom/app/Util;\n.super Ljava/lang/Object;\n"; string(util) != want {
+		t.Errorf("Util.smali = %q, want %q", util, want)
+	}
+}
@@ -0,0 +1,114 @@
+package scanner
+
+import (
+	"debug/elf"
+	"io"
+	"slices"
+)
+
+// NativeKeywordMatch records where a keyword was found in a shared object:
+// in a symbol name, in a string from a read-only data section, or in the
+// raw bytes when the file could not be parsed as ELF.
+type NativeKeywordMatch struct {
+	Keyword string `json:"keyword"`
+	Source  string `json:"source"`
+	Value   string `json:"value"`
+	// Mangled is the C++ mangled name of a symbol, whose demangled form is
+	// in Value and is what the keyword matched.
+	Mangled string `json:"mangled,omitempty"`
+}
+
+// Sources of a NativeKeywordMatch.
+const (
+	NativeSourceSymbol = "symbol"
+	NativeSourceString = "string"
+	NativeSourceRaw    = "raw"
+)
+
+var elfStringSections = []string{".rodata", ".rodata.str1.1", ".rodata.str1.4", ".rodata.str1.8", ".data.rel.ro"}
+
+// elfSymbols returns the names of the exported, imported and (when not
+// stripped) static symbols of an ELF shared object.
+func elfSymbols(file *elf.File) []string {
+	var symbols []string
+	seen := make(map[string]bool)
+	for _, load := range []func() ([]elf.Symbol, error){file.DynamicSymbols, file.Symbols} {
+		entries, err := load()
+		if err != nil {
+			continue
+		}
+		for _, symbol := range entries {
+			if symbol.Name != "" && !seen[symbol.Name] {
+				seen[symbol.Name] = true
+				symbols = append(symbols, symbol.Name)
+			}
+		}
+	}
+	return symbols
+}
+
+// searchKeywordsInELF matches keywords against the symbol names of a shared
+// object, demangling C++ ones, and, for the keywords not found there,
+// against the printable strings of its read-only data sections, which are
+// streamed rather than loaded. It fails when r is not a valid ELF file.
+func searchKeywordsInELF(r io.ReaderAt, keywords []string, matcher keywordMatcher) ([]NativeKeywordMatch, error) {
+	file, err := elf.NewFile(r)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	symbols := elfSymbols(file)
+	names := make([]string, len(symbols))
+	for i, symbol := range symbols {
+		names[i] = symbol
+		if name, ok := demangleSymbol(symbol); ok {
+			names[i] = name
+		}
+	}
+	found := make(map[string]NativeKeywordMatch)
+	for _, keyword := range keywords {
+		if i, ok := findContaining(names, keyword, matcher); ok {
+			found[keyword] = symbolMatch(keyword, symbols[i], names[i])
+		}
+	}
+
+	for _, section := range file.Sections {
+		if !slices.Contains(elfStringSections, section.Name) || section.Type == elf.SHT_NOBITS || len(found) == len(keywords) {
+			continue
+		}
+		scanPrintableStrings(section.Open(), 4, func(str printableString) {
+			for _, keyword := range keywords {
+				if _, done := found[keyword]; !done && matcher.contains(str.Value, keyword) {
+					found[keyword] = NativeKeywordMatch{Keyword: keyword, Source: NativeSourceString, Value: str.Value}
+				}
+			}
+		})
+	}
+
+	var matches []NativeKeywordMatch
+	for _, keyword := range keywords {
+		if match, ok := found[keyword]; ok {
+			matches = append(matches, match)
+		}
+	}
+	return matches, nil
+}
+
+// symbolMatch records a keyword found in the demangled name of symbol.
+func symbolMatch(keyword, symbol, name string) NativeKeywordMatch {
+	match := NativeKeywordMatch{Keyword: keyword, Source: NativeSourceSymbol, Value: name}
+	if name != symbol {
+		match.Mangled = symbol
+	}
+	return match
+}
+
+func findContaining(values []string, keyword string, matcher keywordMatcher) (int, bool) {
+	for i, value := range values {
+		if matcher.contains(value, keyword) {
+			return i, true
+		}
+	}
+	return -1, false
+}
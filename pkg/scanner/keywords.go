@@ -0,0 +1,243 @@
+package scanner
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var root_detection_keywords = []string{"com.noshufou.android.su", "com.noshufou.android.su.elite", "eu.chainfire.supersu", "com.koushikdutta.superuser", "com.thirdparty.superuser", "com.yellowes.su", "com.koushikdutta.rommanager", "com.koushikdutta.rommanager.license", "com.dimonvideo.luckypatcher", "com.chelpus.lackypatch", "com.ramdroid.appquarantine", "com.ramdroid.appquarantinepro", "com.devadvance.rootcloak", "com.devadvance.rootcloakplus", "de.robv.android.xposed.installer", "com.saurik.substrate", "com.zachspong.temprootremovejb", "com.amphoras.hidemyroot", "com.amphoras.hidemyrootadfree", "com.formyhm.hiderootPremium", "com.formyhm.hideroot", "me.phh.superuser", "eu.chainfire.supersu.pro", "com.kingouser.com", "com.android.vending.billing.InAppBillingService.COIN", "com.topjohnwu.magisk", "su", "busybox", "supersu", "Superuser.apk", "KingoUser.apk", "SuperSu.apk", "magisk", "ro.build.selinux", "ro.debuggable", "service.adb.root", "ro.secure", "root", "test-keys", "superuser", "Superuser", "daemonsu", "99SuperSUDaemon", ".has_su_daemon", "/system/app/Superuser.apk", "/system/xbin/su", "/system/usr/we-need-root", "/data/local/bin/su", "/data/local/su", "/data/local/xbin/su", "/dev/com.koushikdutta.superuser.daemon/", "/sbin/su", "/system/bin/failsafe/su", "/system/bin/su", "/su/bin/su", "/system/sd/xbin/su", "/system/xbin/busybox", "/system/xbin/daemonsu", "/system/sbin/su", "/vendor/bin/su", "/cache/su", "/data/su", "/dev/su", "/system/bin/.ext/su", "/system/usr/we-need-root/su", "/system/app/Kinguser.apk", "/data/adb/magisk", "/sbin/.magisk", "/cache/.disable_magisk", "/dev/.magisk.unblock", "/cache/magisk.log", "/data/adb/magisk.img", "/data/adb/magisk.db", "/data/adb/magisk_simple", "/init.magisk.rc", "/system/xbin/ku.sud", "/data/adb/ksu", "/data/adb/ksud", "me.weishu.kernelsu"}
+var emulator_detection_keywords = []string{"ro.build.product", "ro.build.fingerprint", "init.svc.qemud", "init.svc.qemu-props", "qemu.hw.mainkeys", "qemu.sf.fake_camera", "qemu.sf.lcd_density", "ro.hardware", "ro.kernel.android.qemud", "ro.kernel.qemu.gles", "ro.kernel.qemu", "ro.product.device", "ro.product.model", "ro.product.name", "ro.serialno", "ueventd.android_x86.rc", "x86.prop", "ueventd.ttVM_x86.rc", "init.ttVM_x86.rc", "fstab.ttVM_x86", "fstab.vbox86", "init.vbox86.rc", "ueventd.vbox86.rc", "/dev/socket/qemud", "/dev/qemu_pipe", "/system/lib/libc_malloc_debug_qemu.so", "/sys/qemu_trace", "/system/bin/qemu-props", "/dev/socket/genyd", "/dev/socket/baseband_genyd", "/proc/tty/drivers", "/proc/cpuinfo", "genymotion", "geny", "emulator", "nox", "/dev/qemu_trace", "/system/bin/netcfg"}
+var hardware_profile_keywords = []string{"availableProcessors", "getSensorList", "getDefaultSensor"}
+var frida_detection_keywords = []string{"27042", "frida", "27043", "FridaGadget", "frida-server", "re.frida.server", "frida-agent", "linjector", "gum-js-loop", "gmain", "/data/local/tmp"}
+var xposed_detection_keywords = []string{"xposed", "XposedBridge", "EdXposed", "lsposed", "org.lsposed.manager"}
+var file_integrity_keywords = []string{"MessageDigest", "getPackageInfo", "signature"}
+var attestation_keywords = []string{"com.google.android.play.core.integrity", "com/google/android/play/core/integrity", "IntegrityManager", "IntegrityTokenRequest", "IntegrityTokenResponse", "StandardIntegrityManager", "com.google.android.gms.safetynet", "com/google/android/gms/safetynet", "SafetyNetApi", "SafetyNetClient", "attest", "nonce"}
+var debugger_detection_keywords = []string{"TracerPid", "/proc/self/status", "android.os.Debug", "android/os/Debug", "isDebuggerConnected", "waitForDebugger", "ptrace", "PTRACE_TRACEME"}
+var key_attestation_keywords = []string{"KeyAttestation", "attestKey", "setAttestationChallenge", "getAttestationChallenge", "StrongBox", "TRUSTED_ENVIRONMENT", "isInsideSecureHardware", "1.3.6.1.4.1.11129.2.1.17"}
+var vpn_proxy_keywords = []string{"tun0", "ppp0", "pptp", "getNetworkInterfaces", "http.proxyHost", "https.proxyHost", "http.proxyPort", "getDefaultProxy", "getHttpProxy"}
+var hook_detection_keywords = []string{"getStackTrace", "com.android.internal.os.ZygoteInit", "dalvik.system.DexClassLoader", "dalvik/system/DexClassLoader", "InMemoryDexClassLoader"}
+var boot_integrity_keywords = []string{"ro.bootloader", "ro.bootmode", "ro.boot.verifiedbootstate", "ro.boot.flash.locked", "vbmeta", "avb"}
+
+// Path keywords are directories; a method probing a file below one of them
+// is reported with the full path it probes rather than the bare directory.
+var pathPrefixKeywords = []string{"/data/local/tmp"}
+
+type KeywordCategory struct {
+	ID       string
+	Name     string
+	Keywords []string
+	Severity string
+	// Weights overrides the default weight of individual keywords; see
+	// Scanner.KeywordWeight.
+	Weights map[string]int
+}
+
+// DefaultCategories returns the built-in keyword categories.
+func DefaultCategories() []KeywordCategory {
+	return []KeywordCategory{
+		{"root", "Rooted Device Detection", root_detection_keywords, "high", nil},
+		{"emulator", "Emulator Detection", emulator_detection_keywords, "medium", nil},
+		{"hardware", "Emulator Detection (Hardware Profile)", hardware_profile_keywords, "low", nil},
+		{"frida", "Frida Detection", frida_detection_keywords, "high", nil},
+		{"xposed", "Xposed/LSPosed Detection", xposed_detection_keywords, "high", nil},
+		{"integrity", "File Integrity Checks", file_integrity_keywords, "high", nil},
+		{"attestation", "Play Integrity / SafetyNet Attestation", attestation_keywords, "high", map[string]int{"attest": 1, "nonce": 1}},
+		{"keystore", "Hardware Key Attestation", key_attestation_keywords, "high", nil},
+		{"boot", "Boot Integrity", boot_integrity_keywords, "medium", nil},
+		{"debugger", "Debugger Detection", debugger_detection_keywords, "high", nil},
+		{"vpn", "VPN/Proxy Detection", vpn_proxy_keywords, "medium", nil},
+		{"hooking", "Hook Detection (Class Loader / Stack Trace)", hook_detection_keywords, "medium", map[string]int{"getStackTrace": 1}},
+	}
+}
+
+// Keywords starting with RegexKeywordPrefix are regular expressions rather
+// than literal substrings, e.g. `re:ro\.build\.\w+`.
+const RegexKeywordPrefix = "re:"
+
+// CompileKeywordPatterns compiles every regex keyword, case-insensitively
+// unless caseSensitive is set, keyed by the keyword as written, and reports
+// the first invalid pattern. Literal keywords are skipped.
+func CompileKeywordPatterns(keywords []string, caseSensitive bool) (map[string]*regexp.Regexp, error) {
+	patterns := make(map[string]*regexp.Regexp)
+	for _, keyword := range keywords {
+		keyword = strings.TrimSpace(keyword)
+		expression, ok := strings.CutPrefix(keyword, RegexKeywordPrefix)
+		if !ok {
+			continue
+		}
+		pattern, err := regexp.Compile(expression)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex keyword %q: %v", keyword, err)
+		}
+		if !caseSensitive {
+			pattern = regexp.MustCompile("(?i)" + expression)
+		}
+		patterns[keyword] = pattern
+	}
+	return patterns, nil
+}
+
+// CategoryKeywordUnion returns the keywords of all categories, each once.
+func CategoryKeywordUnion(categories []KeywordCategory) []string {
+	var keywords []string
+	seen := make(map[string]struct{})
+	for _, category := range categories {
+		for _, keyword := range category.Keywords {
+			if _, dup := seen[keyword]; dup {
+				continue
+			}
+			seen[keyword] = struct{}{}
+			keywords = append(keywords, keyword)
+		}
+	}
+	return keywords
+}
+
+type KeywordDuplicate struct {
+	Keyword    string
+	Categories []string
+}
+
+// FindDuplicateKeywords reports keywords listed more than once inside the
+// same category and keywords shared between categories.
+func FindDuplicateKeywords(categories []KeywordCategory) (within, across []KeywordDuplicate) {
+	owners := make(map[string][]string)
+	var order []string
+	for _, category := range categories {
+		seen := make(map[string]bool)
+		for _, keyword := range category.Keywords {
+			if seen[keyword] {
+				within = append(within, KeywordDuplicate{keyword, []string{category.Name}})
+				continue
+			}
+			seen[keyword] = true
+			if _, ok := owners[keyword]; !ok {
+				order = append(order, keyword)
+			}
+			owners[keyword] = append(owners[keyword], category.Name)
+		}
+	}
+
+	for _, keyword := range order {
+		if len(owners[keyword]) > 1 {
+			across = append(across, KeywordDuplicate{keyword, owners[keyword]})
+		}
+	}
+	return within, across
+}
+
+// DedupeKeywordCategories drops repeated keywords inside each category.
+// Keywords shared between categories are kept, since a keyword may
+// legitimately indicate more than one kind of check.
+func DedupeKeywordCategories(categories []KeywordCategory) ([]KeywordCategory, []KeywordDuplicate) {
+	within, _ := FindDuplicateKeywords(categories)
+	if len(within) == 0 {
+		return categories, nil
+	}
+
+	deduped := make([]KeywordCategory, len(categories))
+	for i, category := range categories {
+		deduped[i] = category
+		deduped[i].Keywords = nil
+		for _, keyword := range category.Keywords {
+			if !slices.Contains(deduped[i].Keywords, keyword) {
+				deduped[i].Keywords = append(deduped[i].Keywords, keyword)
+			}
+		}
+	}
+	return deduped, within
+}
+
+// categoryAliases maps the IDs of built-in categories that were split to
+// the categories replacing them.
+var categoryAliases = map[string][]string{"runtime": {"frida", "xposed"}}
+
+// CategoryIDs splits a comma-separated list of category IDs, replacing the
+// ID of a split built-in category with those of its replacements unless one
+// of the categories still has that ID.
+func CategoryIDs(value string, categories []KeywordCategory) []string {
+	var ids []string
+	for _, id := range strings.Split(value, ",") {
+		if id = strings.TrimSpace(id); id == "" {
+			continue
+		}
+		if replacements, ok := categoryAliases[id]; ok && !slices.ContainsFunc(categories, func(c KeywordCategory) bool { return c.ID == id }) {
+			ids = append(ids, replacements...)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// SelectCategories keeps only the keyword categories and structural
+// detectors whose IDs are listed in the comma-separated value, in their
+// original order.
+func SelectCategories(categories []KeywordCategory, detectors []StructuralDetector, value string) ([]KeywordCategory, []StructuralDetector, error) {
+	selected := make(map[string]bool)
+	for _, id := range CategoryIDs(value, categories) {
+		if !slices.ContainsFunc(categories, func(c KeywordCategory) bool { return c.ID == id }) &&
+			!slices.ContainsFunc(detectors, func(d StructuralDetector) bool { return d.ID == id }) {
+			return nil, nil, fmt.Errorf("unknown category %q", id)
+		}
+		selected[id] = true
+	}
+
+	var selectedCategories []KeywordCategory
+	for _, category := range categories {
+		if selected[category.ID] {
+			selectedCategories = append(selectedCategories, category)
+		}
+	}
+	var selectedDetectors []StructuralDetector
+	for _, detector := range detectors {
+		if selected[detector.ID] {
+			selectedDetectors = append(selectedDetectors, detector)
+		}
+	}
+	return selectedCategories, selectedDetectors, nil
+}
+
+// KeywordInCategory reports whether a found keyword belongs to a category
+// keyword: they are equal, or the category keyword is a path prefix of it.
+func KeywordInCategory(keyword, categoryKeyword string) bool {
+	if keyword == categoryKeyword {
+		return true
+	}
+	return slices.Contains(pathPrefixKeywords, categoryKeyword) && strings.HasPrefix(keyword, categoryKeyword+"/")
+}
+
+// FilterCategoryKeywords keeps, for every source such as a method or a file,
+// the found keywords that belong to the category keywords.
+func FilterCategoryKeywords(categoryKeywords []string, keywordsBySource map[string][]string) map[string][]string {
+	filtered := make(map[string][]string)
+	for source, keywords := range keywordsBySource {
+		var filteredKeywords []string
+		for _, keyword := range keywords {
+			for _, categoryKeyword := range categoryKeywords {
+				if KeywordInCategory(keyword, categoryKeyword) {
+					filteredKeywords = append(filteredKeywords, keyword)
+				}
+			}
+		}
+		if len(filteredKeywords) > 0 {
+			filtered[source] = filteredKeywords
+		}
+	}
+	return filtered
+}
+
+func probedPaths(methodContent, directory string) []string {
+	var paths []string
+	for _, literal := range ConstStrings(methodContent) {
+		if strings.HasPrefix(literal, directory+"/") && !slices.Contains(paths, literal) {
+			paths = append(paths, literal)
+		}
+	}
+	if len(paths) == 0 {
+		paths = append(paths, directory)
+	}
+	return paths
+}
@@ -0,0 +1,473 @@
+package scanner
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// NativeIntegrityRef is a digest embedded in a native library near integrity
+// related strings, with the library or APK it pins when it could be
+// resolved.
+type NativeIntegrityRef struct {
+	Digest string `json:"digest"`
+	Target string `json:"target,omitempty"`
+}
+
+// NativeResults holds, per .so file, the keywords found in it, where they
+// were found and the embedded digests.
+type NativeResults struct {
+	// Files is the number of .so files searched.
+	Files     int                             `json:"files"`
+	Keywords  map[string][]string             `json:"keywords"`
+	Matches   map[string][]NativeKeywordMatch `json:"matches,omitempty"`
+	Integrity map[string][]NativeIntegrityRef `json:"integrity,omitempty"`
+	// Skipped are the .so files left out for exceeding Options.MaxFileSize.
+	Skipped []SkippedFile `json:"skipped,omitempty"`
+	// ABIs lists, with Options.GroupABIs, the ABIs whose copy of each
+	// library had keywords or digests; the other fields are then keyed by
+	// library name instead of path.
+	ABIs map[string][]string `json:"abis,omitempty"`
+}
+
+const nativeIntegrityWindow = 1024
+
+// .so files are read nativeChunkSize bytes at a time; raw keyword matches
+// are searched in each chunk together with the last nativeChunkOverlap bytes
+// of the previous ones.
+const (
+	nativeChunkSize    = 1 << 20
+	nativeChunkOverlap = 4096
+)
+
+var (
+	hexDigestPattern      = regexp.MustCompile(`(?i)\b(?:[0-9a-f]{64}|[0-9a-f]{40}|[0-9a-f]{32})\b`)
+	integrityContextTerms = []string{"sha1", "sha256", "sha-256", "md5", "digest", "checksum", "integrity", "verify", "tamper", "evp_", "/proc/self/maps", "dl_iterate_phdr"}
+)
+
+type printableString struct {
+	Offset int
+	Value  string
+}
+
+// scanPrintableStrings reads r in chunks and calls fn with every run of at
+// least minLength printable ASCII bytes, with its offset in r, so runs
+// spanning chunk boundaries are reported whole.
+func scanPrintableStrings(r io.Reader, minLength int, fn func(printableString)) error {
+	buffer := make([]byte, nativeChunkSize)
+	var current []byte
+	start, offset := 0, 0
+	flush := func() {
+		if len(current) >= minLength {
+			fn(printableString{start, string(current)})
+		}
+		current = current[:0]
+	}
+
+	for {
+		n, err := r.Read(buffer)
+		for i, b := range buffer[:n] {
+			if b >= 0x20 && b < 0x7f {
+				if len(current) == 0 {
+					start = offset + i
+				}
+				current = append(current, b)
+				continue
+			}
+			flush()
+		}
+		offset += n
+		if err == io.EOF {
+			flush()
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// fileDigests maps the md5, sha1 and sha256 hex digests of every file in
+// paths to its display name, so embedded digests can be resolved to the
+// library or APK they pin.
+func fileDigests(paths map[string]string) map[string]string {
+	digests := make(map[string]string)
+	for path, name := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		md5Hash, sha1Hash, sha256Hash := md5.New(), sha1.New(), sha256.New()
+		_, err = io.Copy(io.MultiWriter(md5Hash, sha1Hash, sha256Hash), file)
+		file.Close()
+		if err != nil {
+			continue
+		}
+		digests[hex.EncodeToString(md5Hash.Sum(nil))] = name
+		digests[hex.EncodeToString(sha1Hash.Sum(nil))] = name
+		digests[hex.EncodeToString(sha256Hash.Sum(nil))] = name
+	}
+	return digests
+}
+
+// searchSharedObject searches one shared object for keywords, as
+// searchKeywordsInELF does or in its raw bytes when it is not a valid ELF
+// file, and for embedded digests. The file is streamed in chunks rather than
+// read into memory; raw keyword matches may span chunks by up to
+// nativeChunkOverlap bytes.
+func searchSharedObject(path string, keywords []string, matcher keywordMatcher, digests map[string]string) ([]NativeKeywordMatch, []NativeIntegrityRef, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	matches, err := searchKeywordsInELF(file, keywords, matcher)
+	raw := err != nil
+	if raw {
+		slog.Debug("not a valid ELF file, searching its raw bytes", "file", path, "error", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, nil, err
+	}
+
+	finder := newIntegrityFinder(digests)
+	found := make(map[string]bool)
+	var tail []byte
+	reader := io.TeeReader(file, writerFunc(func(chunk []byte) {
+		if !raw {
+			return
+		}
+		window := append(tail, chunk...)
+		for _, keyword := range keywords {
+			if !found[keyword] && matcher.contains(string(window), keyword) {
+				found[keyword] = true
+			}
+		}
+		tail = append(tail[:0], window[max(0, len(window)-nativeChunkOverlap):]...)
+	}))
+	if err := scanPrintableStrings(reader, 4, finder.add); err != nil {
+		return nil, nil, err
+	}
+
+	if raw {
+		for _, keyword := range keywords {
+			if found[keyword] {
+				matches = append(matches, NativeKeywordMatch{Keyword: keyword, Source: NativeSourceRaw})
+			}
+		}
+	}
+	return matches, finder.finish(), nil
+}
+
+// writerFunc is an io.Writer calling a function with every write.
+type writerFunc func([]byte)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	f(p)
+	return len(p), nil
+}
+
+// integrityFinder finds the digests embedded in a library from its printable
+// strings, given in offset order, keeping only the strings within
+// nativeIntegrityWindow bytes of the current one. A digest is reported when
+// it is the digest of a known file or has integrity-related strings around
+// it.
+type integrityFinder struct {
+	digests map[string]string
+	window  []printableString
+	pending []pendingDigest
+	seen    map[string]struct{}
+	refs    []NativeIntegrityRef
+}
+
+// pendingDigest is a digest still collecting the strings that follow it.
+type pendingDigest struct {
+	digest        string
+	offset        int
+	target        string
+	resolved      bool
+	nearIntegrity bool
+}
+
+func newIntegrityFinder(digests map[string]string) *integrityFinder {
+	return &integrityFinder{digests: digests, seen: make(map[string]struct{})}
+}
+
+func (f *integrityFinder) add(str printableString) {
+	for len(f.pending) > 0 && str.Offset-f.pending[0].offset > nativeIntegrityWindow {
+		f.report(f.pending[0])
+		f.pending = f.pending[1:]
+	}
+	for i := range f.pending {
+		digest := &f.pending[i]
+		digest.nearIntegrity, digest.target = inspectNeighbour(str.Value, digest.nearIntegrity, digest.target, digest.resolved)
+	}
+
+	for len(f.window) > 0 && str.Offset-f.window[0].Offset > nativeIntegrityWindow {
+		f.window = f.window[1:]
+	}
+	for _, match := range hexDigestPattern.FindAllString(str.Value, -1) {
+		digest := pendingDigest{digest: strings.ToLower(match), offset: str.Offset}
+		if _, dup := f.seen[digest.digest]; dup {
+			continue
+		}
+		digest.target, digest.resolved = f.digests[digest.digest]
+		for j := len(f.window) - 1; j >= 0; j-- {
+			digest.nearIntegrity, digest.target = inspectNeighbour(f.window[j].Value, digest.nearIntegrity, digest.target, digest.resolved)
+		}
+		f.pending = append(f.pending, digest)
+	}
+	f.window = append(f.window, str)
+}
+
+func (f *integrityFinder) report(digest pendingDigest) {
+	if _, dup := f.seen[digest.digest]; dup || !digest.resolved && !digest.nearIntegrity {
+		return
+	}
+	f.seen[digest.digest] = struct{}{}
+	f.refs = append(f.refs, NativeIntegrityRef{Digest: digest.digest, Target: digest.target})
+}
+
+// finish reports the digests still pending and returns all found.
+func (f *integrityFinder) finish() []NativeIntegrityRef {
+	for _, digest := range f.pending {
+		f.report(digest)
+	}
+	f.pending = nil
+	return f.refs
+}
+
+func inspectNeighbour(value string, nearIntegrity bool, target string, resolved bool) (bool, string) {
+	lower := strings.ToLower(value)
+	for _, term := range integrityContextTerms {
+		if strings.Contains(lower, term) {
+			nearIntegrity = true
+			break
+		}
+	}
+	if !resolved && target == "" && (strings.HasSuffix(lower, ".so") || strings.HasSuffix(lower, ".apk")) {
+		target = filepath.Base(value)
+	}
+	return nearIntegrity, target
+}
+
+// preferredABIs orders ABIs for picking the copy of a library shipped for
+// several of them; ABIs not listed come last.
+var preferredABIs = []string{"arm64-v8a", "armeabi-v7a", "armeabi", "x86_64", "x86", "riscv64", "mips64", "mips"}
+
+// libraryABI returns the ABI directory of a library below libDir, or ""
+// for one directly in it.
+func libraryABI(libDir, path string) string {
+	relative, err := filepath.Rel(libDir, path)
+	if err != nil {
+		return ""
+	}
+	if abi, _, ok := strings.Cut(filepath.ToSlash(relative), "/"); ok {
+		return abi
+	}
+	return ""
+}
+
+// selectLibraries returns the .so files below libDir to search, in lexical
+// order: those of Options.ABIs only, when set, and unless Options.AllABIs
+// is set, one copy of each library name, from the first ABI in Options.ABIs
+// or preferredABIs that ships it.
+func (s *Scanner) selectLibraries(libDir string) ([]string, error) {
+	order := preferredABIs
+	if len(s.opts.ABIs) > 0 {
+		order = s.opts.ABIs
+	}
+	rank := func(abi string) int {
+		if i := slices.Index(order, abi); i >= 0 {
+			return i
+		}
+		return len(order)
+	}
+
+	var paths []string
+	chosen := make(map[string]int)
+	err := Walk(libDir, s.opts.FollowSymlinks, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(info.Name(), ".so") {
+			return nil
+		}
+		abi := libraryABI(libDir, path)
+		if len(s.opts.ABIs) > 0 && !slices.Contains(s.opts.ABIs, abi) {
+			return nil
+		}
+		if s.opts.AllABIs {
+			paths = append(paths, path)
+			return nil
+		}
+		if i, ok := chosen[info.Name()]; ok {
+			if rank(abi) < rank(libraryABI(libDir, paths[i])) {
+				paths[i] = path
+			}
+			return nil
+		}
+		chosen[info.Name()] = len(paths)
+		paths = append(paths, path)
+		return nil
+	})
+	slices.Sort(paths)
+	return paths, err
+}
+
+// tooLarge reports whether a file exceeds Options.MaxFileSize.
+func (s *Scanner) tooLarge(info os.FileInfo) bool {
+	return s.opts.MaxFileSize > 0 && info.Size() > s.opts.MaxFileSize
+}
+
+// searchLibrary searches one .so file with Options.StringsTool, falling back
+// to searchSharedObject when it is not set or fails.
+func (s *Scanner) searchLibrary(ctx context.Context, path, relativePath string, keywords []string, matcher keywordMatcher, digests map[string]string) ([]NativeKeywordMatch, []NativeIntegrityRef, error) {
+	if s.opts.StringsTool != "" {
+		matches, refs, err := searchWithStringsTool(ctx, s.opts.StringsTool, path, keywords, matcher, digests)
+		if err == nil || ctx.Err() != nil {
+			return matches, refs, err
+		}
+		slog.Warn("falling back to the built-in .so search", "file", relativePath, "error", err)
+	}
+	return searchSharedObject(path, keywords, matcher, digests)
+}
+
+// SearchInSoFiles searches the .so files below the lib directory of a
+// decoded APK, as selected by Options.ABIs and Options.AllABIs, for
+// keywords, matched as by ContainsKeyword, and for digests of the searched
+// libraries or of apkFile embedded in them. Files are searched by Options.Workers
+// goroutines and merged in path order, so the results do not depend on
+// which finishes first. It stops early with ctx's error when it is
+// cancelled; progress may be nil.
+func (s *Scanner) SearchInSoFiles(ctx context.Context, directory, apkFile string, keywords []string, progress Progress) (*NativeResults, error) {
+	patterns, err := CompileKeywordPatterns(keywords, s.opts.CaseSensitive)
+	if err != nil {
+		return nil, err
+	}
+	matcher := s.matcher(patterns)
+
+	results := &NativeResults{
+		Keywords:  map[string][]string{},
+		Matches:   map[string][]NativeKeywordMatch{},
+		Integrity: map[string][]NativeIntegrityRef{},
+	}
+
+	selected, err := s.selectLibraries(filepath.Join(directory, "lib"))
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	libraries := map[string]string{apkFile: filepath.Base(apkFile)}
+	for _, path := range selected {
+		relativePath := filepath.ToSlash(strings.TrimPrefix(path, filepath.Join(directory)))
+		if info, err := os.Stat(path); err == nil && s.tooLarge(info) {
+			slog.Info("skipping large .so file", "file", relativePath, "size", info.Size(), "limit", s.opts.MaxFileSize)
+			results.Skipped = append(results.Skipped, SkippedFile{Path: relativePath, Error: fmt.Sprintf("%d bytes, over the %d byte limit", info.Size(), s.opts.MaxFileSize)})
+			continue
+		}
+		paths = append(paths, path)
+		libraries[path] = relativePath
+	}
+	// Only the libraries searched are hashed: with --abi, hashing the
+	// copies of every other ABI would read the whole lib directory.
+	digests := fileDigests(libraries)
+	if progress != nil {
+		progress.Count("Searching for keywords in .so files", len(paths))
+	}
+	slog.Debug("scanning .so files", "directory", filepath.Join(directory, "lib"), "files", len(paths), "skipped", len(results.Skipped))
+
+	workers := s.opts.Workers
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+
+	type libraryResult struct {
+		matches []NativeKeywordMatch
+		refs    []NativeIntegrityRef
+		err     error
+	}
+	found := make([]libraryResult, len(paths))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < min(workers, len(paths)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+				relativePath := filepath.ToSlash(strings.TrimPrefix(paths[index], filepath.Join(directory)))
+				result := &found[index]
+				result.matches, result.refs, result.err = s.searchLibrary(ctx, paths[index], relativePath, keywords, matcher, digests)
+				slog.Debug("scanned .so file", "file", relativePath, "matches", len(result.matches))
+				if progress != nil {
+					progress.Increment()
+				}
+			}
+		}()
+	}
+	for index := range paths {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- index
+	}
+	close(jobs)
+	wg.Wait()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	if s.opts.GroupABIs {
+		results.ABIs = map[string][]string{}
+	}
+	for index, path := range paths {
+		result := found[index]
+		if result.err != nil {
+			return nil, result.err
+		}
+		results.Files++
+
+		key := filepath.ToSlash(strings.TrimPrefix(path, filepath.Join(directory)))
+		if s.opts.GroupABIs {
+			key = filepath.Base(path)
+			if len(result.matches) > 0 || len(result.refs) > 0 {
+				results.ABIs[key] = append(results.ABIs[key], libraryABI(filepath.Join(directory, "lib"), path))
+				slices.Sort(results.ABIs[key])
+			}
+		}
+		// Grouped copies of a library keep the first match of a keyword
+		// and each digest once.
+		for _, match := range result.matches {
+			if !slices.Contains(results.Keywords[key], match.Keyword) {
+				results.Keywords[key] = append(results.Keywords[key], match.Keyword)
+				results.Matches[key] = append(results.Matches[key], match)
+			}
+		}
+		for _, ref := range result.refs {
+			if !slices.Contains(results.Integrity[key], ref) {
+				results.Integrity[key] = append(results.Integrity[key], ref)
+			}
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,110 @@
+package scanner
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSearchInSoFilesMatchingModes(t *testing.T) {
+	directory := t.TempDir()
+	library := filepath.Join(directory, "lib", "arm64-v8a", "libcheck.so")
+	if err := os.MkdirAll(filepath.Dir(library), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// Not an ELF file, so its raw bytes are searched.
+	content := "\x00subscribe_events\x00isSU\x00frida_agent_main\x00Frida detected\x00"
+	if err := os.WriteFile(library, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		opts Options
+		want []string
+	}{
+		{"substring, case-insensitive", Options{}, []string{"frida", "su"}},
+		{"word boundary", Options{WordBoundary: true}, []string{"frida"}},
+		{"case-sensitive", Options{CaseSensitive: true}, []string{"frida", "su"}},
+		{"case-sensitive word boundary", Options{CaseSensitive: true, WordBoundary: true}, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scanner, err := New(test.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			results, err := scanner.SearchInSoFiles(context.Background(), directory, filepath.Join(directory, "app.apk"), []string{"su", "frida"}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := results.Keywords["/lib/arm64-v8a/libcheck.so"]
+			slices.Sort(got)
+			if !slices.Equal(got, test.want) {
+				t.Errorf("keywords = %v, want %v (results %v)", got, test.want, results.Keywords)
+			}
+		})
+	}
+}
+
+func TestKeywordMatcher(t *testing.T) {
+	tests := []struct {
+		value, keyword string
+		matcher        keywordMatcher
+		want           bool
+	}{
+		{"_Z9subscribev", "su", keywordMatcher{}, true},
+		{"_Z9subscribev", "su", keywordMatcher{wordBoundary: true}, false},
+		{"/system/bin/su", "su", keywordMatcher{wordBoundary: true}, true},
+		{"/system/bin/SU", "su", keywordMatcher{wordBoundary: true}, true},
+		{"/system/bin/SU", "su", keywordMatcher{caseSensitive: true}, false},
+		{"checkFrida()", "Frida", keywordMatcher{caseSensitive: true}, true},
+		{"frida-server", "frida", keywordMatcher{caseSensitive: true, wordBoundary: true}, true},
+	}
+	for _, test := range tests {
+		if got := test.matcher.contains(test.value, test.keyword); got != test.want {
+			t.Errorf("%+v.contains(%q, %q) = %v, want %v", test.matcher, test.value, test.keyword, got, test.want)
+		}
+	}
+}
+
+func TestSearchInSoFilesDigestsFollowABIs(t *testing.T) {
+	directory := t.TempDir()
+	write := func(path, content string) string {
+		t.Helper()
+		path = filepath.Join(directory, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	digest := func(content string) string {
+		sum := sha256.Sum256([]byte(content))
+		return hex.EncodeToString(sum[:])
+	}
+
+	apk := write("app.apk", "PK apk")
+	write("lib/x86/libother.so", "other")
+	// The digests of the APK and of a library of an ABI left out by --abi.
+	write("lib/arm64-v8a/libguard.so", "\x00"+digest("PK apk")+"\x00"+digest("other")+"\x00")
+
+	scanner, err := New(Options{ABIs: []string{"arm64-v8a"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := scanner.SearchInSoFiles(context.Background(), directory, apk, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []NativeIntegrityRef{{Digest: digest("PK apk"), Target: "app.apk"}}
+	if got := results.Integrity["/lib/arm64-v8a/libguard.so"]; !slices.Equal(got, want) {
+		t.Errorf("integrity = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,92 @@
+package scanner
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// PackageFilter selects the classes to scan by package. Patterns are globs
+// over the class path in slash form, such as com/google or com/*/ads; dots
+// may be used instead of slashes. A pattern matches a class when it matches
+// the class path or one of its parent packages, so com/google matches every
+// class below com/google/.
+type PackageFilter struct {
+	// Include, when not empty, limits the scan to the classes matching one
+	// of its patterns.
+	Include []string
+	// Exclude leaves out the classes matching one of its patterns, even
+	// when they are included.
+	Exclude []string
+}
+
+// ParsePackagePatterns splits a comma-separated list of package globs and
+// reports the first malformed one.
+func ParsePackagePatterns(value string) ([]string, error) {
+	var patterns []string
+	for _, pattern := range strings.Split(value, ",") {
+		pattern = strings.Trim(strings.ReplaceAll(strings.TrimSpace(pattern), ".", "/"), "/")
+		if pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid package pattern %q: %v", pattern, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// matchPackage reports whether pattern matches classPath or one of its
+// parent packages and, failing that, whether it could still match a class
+// below classPath when classPath is a package directory.
+func matchPackage(pattern, classPath string) (matched, possible bool) {
+	patternSegments := strings.Split(pattern, "/")
+	classSegments := strings.Split(classPath, "/")
+	for i := 0; i < len(patternSegments) && i < len(classSegments); i++ {
+		if ok, _ := path.Match(patternSegments[i], classSegments[i]); !ok {
+			return false, false
+		}
+	}
+	return len(classSegments) >= len(patternSegments), true
+}
+
+// Match reports whether the class at classPath, e.g. com/app/Foo, is scanned.
+func (f PackageFilter) Match(classPath string) bool {
+	for _, pattern := range f.Exclude {
+		if matched, _ := matchPackage(pattern, classPath); matched {
+			return false
+		}
+	}
+	if len(f.Include) == 0 {
+		return true
+	}
+	for _, pattern := range f.Include {
+		if matched, _ := matchPackage(pattern, classPath); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// skipPackage reports whether no class below the package directory at
+// packagePath can be scanned, so the walk can skip it.
+func (f PackageFilter) skipPackage(packagePath string) bool {
+	if packagePath == "." {
+		return false
+	}
+	for _, pattern := range f.Exclude {
+		if matched, _ := matchPackage(pattern, packagePath); matched {
+			return true
+		}
+	}
+	if len(f.Include) == 0 {
+		return false
+	}
+	for _, pattern := range f.Include {
+		if _, possible := matchPackage(pattern, packagePath); possible {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,132 @@
+package scanner
+
+import (
+	"archive/zip"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// classesSmaliDirPattern matches the smali directories decoded from the
+// APK's own dex files: smali for classes.dex and smali_classesN for
+// classesN.dex. Directories of merged splits carry a suffix and do not match.
+var classesSmaliDirPattern = regexp.MustCompile(`^smali(?:_classes(\d+))?$`)
+
+// FindSmaliDirs returns the smali directories of a decoded APK: every
+// directory named smali or smali_<anything>, whatever the apktool version
+// or packer named it. The APK's own dex directories come first in dex order
+// (smali, smali_classes2, ..., smali_classes10), then the others by name.
+// With followSymlinks, links to directories count as directories.
+func FindSmaliDirs(decodedDirectory string, followSymlinks bool) ([]string, error) {
+	entries, err := os.ReadDir(decodedDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		isDir := entry.IsDir()
+		if followSymlinks && entry.Type()&fs.ModeSymlink != 0 {
+			info, err := os.Stat(filepath.Join(decodedDirectory, entry.Name()))
+			isDir = err == nil && info.IsDir()
+		}
+		if isDir && (entry.Name() == "smali" || strings.HasPrefix(entry.Name(), "smali_")) {
+			names = append(names, entry.Name())
+		}
+	}
+	dexIndex := func(name string) int {
+		match := classesSmaliDirPattern.FindStringSubmatch(name)
+		switch {
+		case match == nil:
+			return -1
+		case match[1] == "":
+			return 1
+		}
+		index, _ := strconv.Atoi(match[1])
+		return index
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		ai, bi := dexIndex(a), dexIndex(b)
+		switch {
+		case ai >= 0 && bi >= 0:
+			return ai - bi
+		case ai >= 0:
+			return -1
+		case bi >= 0:
+			return 1
+		}
+		return strings.Compare(a, b)
+	})
+
+	dirs := make([]string, len(names))
+	for i, name := range names {
+		dirs[i] = filepath.Join(decodedDirectory, name)
+	}
+	return dirs, nil
+}
+
+// apkDexPattern matches the dex files of an APK, or of the base module of
+// an App Bundle.
+var apkDexPattern = regexp.MustCompile(`^(?:base/dex/)?classes\d*\.dex$`)
+
+// packerLibraries are native libraries shipped by common Android packers,
+// which decrypt the real dex at runtime.
+var packerLibraries = map[string]string{
+	"libjiagu.so":        "360 Jiagu",
+	"libjiagu_x86.so":    "360 Jiagu",
+	"libjiagu_a64.so":    "360 Jiagu",
+	"libshella.so":       "Tencent Legu",
+	"libshellx.so":       "Tencent Legu",
+	"libsecexe.so":       "Bangcle",
+	"libsecmain.so":      "Bangcle",
+	"libDexHelper.so":    "SecNeo",
+	"libexec.so":         "ijiami",
+	"libexecmain.so":     "ijiami",
+	"libbaiduprotect.so": "Baidu",
+}
+
+// DetectPacking looks for signs that the app is packed, so the decoded smali
+// only holds a loader and a static scan misses most of the code: dex files
+// of the APK that were not decoded to smali, as packers break apktool with
+// malformed dex; dex or jar files under assets/; or the native library of a
+// known packer. It returns one description per sign.
+func DetectPacking(apkFile string, smaliDirs []string) ([]string, error) {
+	zipReader, err := zip.OpenReader(apkFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", apkFile, err)
+	}
+	defer zipReader.Close()
+
+	var signs []string
+	dexFiles := 0
+	packers := make(map[string]bool)
+	for _, file := range zipReader.File {
+		name := file.Name
+		switch base := path.Base(name); {
+		case apkDexPattern.MatchString(name):
+			dexFiles++
+		case strings.HasPrefix(name, "assets/") && (strings.HasSuffix(name, ".dex") || strings.HasSuffix(name, ".jar")):
+			signs = append(signs, fmt.Sprintf("%s may hold code loaded at runtime", name))
+		case strings.HasSuffix(name, ".so") && packerLibraries[base] != "" && !packers[packerLibraries[base]]:
+			packers[packerLibraries[base]] = true
+			signs = append(signs, fmt.Sprintf("%s belongs to the %s packer", base, packerLibraries[base]))
+		}
+	}
+
+	decoded := 0
+	for _, dir := range smaliDirs {
+		if classesSmaliDirPattern.MatchString(filepath.Base(dir)) {
+			decoded++
+		}
+	}
+	if decoded < dexFiles {
+		signs = append([]string{fmt.Sprintf("only %d of the %d classes*.dex files of the APK were decoded to smali", decoded, dexFiles)}, signs...)
+	}
+	return signs, nil
+}
@@ -0,0 +1,59 @@
+package scanner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type protectionLibrary struct {
+	Name    string
+	Package string
+}
+
+// protectionLibraries are detection SDKs recognizable by their package, in
+// slash form.
+var protectionLibraries = []protectionLibrary{
+	{"RootBeer", "com/scottyab/rootbeer"},
+	{"RootBeer Fresh", "com/kimchangyoun/rootbeerFresh"},
+	{"freeRASP", "com/aheaditec/talsec_security"},
+	{"AppSealing", "com/inka/appsealing"},
+	{"LIAPP", "com/lockincomp/liapp"},
+	{"Play Integrity API", "com/google/android/play/core/integrity"},
+	{"SafetyNet Attestation API", "com/google/android/gms/safetynet"},
+}
+
+// ProtectionSDK is a known detection library bundled with the app.
+type ProtectionSDK struct {
+	Name    string `json:"name"`
+	Package string `json:"package"`
+	Classes int    `json:"classes"`
+}
+
+func (p ProtectionSDK) String() string {
+	return fmt.Sprintf("%s (%s, %d classes)", p.Name, p.Package, p.Classes)
+}
+
+// DetectProtectionSDKs returns the protection libraries with classes in
+// any of the smali directories. Only the library packages are walked, and
+// renamed packages are not recognized. Symbolic links are followed as by
+// Walk.
+func DetectProtectionSDKs(smaliDirs []string, followSymlinks bool) []ProtectionSDK {
+	var sdks []ProtectionSDK
+	for _, library := range protectionLibraries {
+		classes := 0
+		for _, smaliDir := range smaliDirs {
+			Walk(filepath.Join(smaliDir, filepath.FromSlash(library.Package)), followSymlinks, func(path string, info os.FileInfo, err error) error {
+				if err == nil && !info.IsDir() && strings.HasSuffix(info.Name(), ".smali") {
+					classes++
+				}
+				return nil
+			})
+		}
+		if classes > 0 {
+			sdks = append(sdks, ProtectionSDK{Name: library.Name, Package: strings.ReplaceAll(library.Package, "/", "."), Classes: classes})
+		}
+	}
+	return sdks
+}
@@ -0,0 +1,122 @@
+package scanner
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	constStringLinePattern = regexp.MustCompile(`^\s*const-string(?:/jumbo)? [vp]\d+, "((?:[^"\\]|\\.)*)"`)
+	constCharPattern       = regexp.MustCompile(`^\s*const(?:/4|/16)? ([vp]\d+), (-?0x[0-9a-fA-F]+|-?\d+)\s*$`)
+	aputCharPattern        = regexp.MustCompile(`^\s*aput-char ([vp]\d+),`)
+	appendCharPattern      = regexp.MustCompile(`^\s*invoke-virtual(?:/range)? \{[vp]\d+, ([vp]\d+)\}, Ljava/lang/StringBuilder;->append\(C\)`)
+)
+
+// reassembledString is a string an obfuscator split into pieces that only
+// join at runtime, with the method line each of its bytes came from.
+type reassembledString struct {
+	Text  string
+	lines []int
+}
+
+func (r *reassembledString) add(text string, line int) {
+	r.Text += text
+	for range text {
+		r.lines = append(r.lines, line)
+	}
+}
+
+// reassembleStrings rebuilds the strings of a method that are split across
+// instructions: the concatenation of all its const-string literals, in
+// order, and the characters stored into char arrays (aput-char) or appended
+// to a StringBuilder (append(C)) from const, const/4 and const/16 values.
+func reassembleStrings(lines []string) []reassembledString {
+	var literals, chars reassembledString
+	pending := make(map[string]string)
+	for i, line := range lines {
+		if match := constStringLinePattern.FindStringSubmatch(line); match != nil {
+			literals.add(unescapeSmali(match[1]), i)
+			continue
+		}
+		if match := constCharPattern.FindStringSubmatch(line); match != nil {
+			delete(pending, match[1])
+			if value, err := strconv.ParseInt(match[2], 0, 32); err == nil && value >= 0x20 && value < 0x7f {
+				pending[match[1]] = string(rune(value))
+			}
+			continue
+		}
+
+		register := ""
+		if match := aputCharPattern.FindStringSubmatch(line); match != nil {
+			register = match[1]
+		} else if match := appendCharPattern.FindStringSubmatch(line); match != nil {
+			register = match[1]
+		}
+		if char, ok := pending[register]; ok {
+			chars.add(char, i)
+		}
+	}
+
+	var reassembled []reassembledString
+	for _, candidate := range []reassembledString{literals, chars} {
+		if len(candidate.Text) > 1 {
+			reassembled = append(reassembled, candidate)
+		}
+	}
+	return reassembled
+}
+
+// unescapeSmali decodes the escapes of a smali string literal, keeping
+// anything it cannot decode as written.
+func unescapeSmali(literal string) string {
+	if !strings.Contains(literal, `\`) {
+		return literal
+	}
+	if unquoted, err := strconv.Unquote(`"` + literal + `"`); err == nil {
+		return unquoted
+	}
+	return literal
+}
+
+// searchReassembled matches the keywords not found directly in a method
+// against its reassembled strings. A match is reported on the line of the
+// first piece of the keyword.
+func (s *Scanner) searchReassembled(lines []string, firstLine int, found map[string]bool) []KeywordMatch {
+	var matches []KeywordMatch
+	for _, candidate := range reassembleStrings(lines) {
+		text := candidate.Text
+		if !s.opts.CaseSensitive {
+			text = strings.ToLower(text)
+		}
+		for _, keyword := range s.keywords {
+			if found[keyword] {
+				continue
+			}
+
+			index := -1
+			if pattern, ok := s.patterns[keyword]; ok {
+				if location := pattern.FindStringIndex(text); location != nil {
+					index = location[0]
+				}
+			} else {
+				needle := keyword
+				if !s.opts.CaseSensitive {
+					needle = strings.ToLower(keyword)
+				}
+				index = KeywordIndex(text, needle, s.opts.WordBoundary)
+			}
+			if index < 0 {
+				continue
+			}
+
+			found[keyword] = true
+			matches = append(matches, KeywordMatch{
+				Keyword: keyword,
+				Line:    firstLine + candidate.lines[index],
+				Context: "reassembled " + strconv.Quote(candidate.Text),
+			})
+		}
+	}
+	return matches
+}
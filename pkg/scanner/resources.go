@@ -0,0 +1,269 @@
+package scanner
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ResourceEntry is a resource of a decoded APK: a string, raw or asset
+// resource, with its ID when public.xml lists it.
+type ResourceEntry struct {
+	Type  string
+	Name  string
+	ID    string
+	Value string
+}
+
+func (r ResourceEntry) String() string {
+	if r.ID != "" {
+		return fmt.Sprintf("%s/%s (%s)", r.Type, r.Name, r.ID)
+	}
+	return fmt.Sprintf("%s/%s", r.Type, r.Name)
+}
+
+type publicXML struct {
+	Entries []struct {
+		Type string `xml:"type,attr"`
+		Name string `xml:"name,attr"`
+		ID   string `xml:"id,attr"`
+	} `xml:"public"`
+}
+
+type stringsXML struct {
+	Strings []struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"string"`
+}
+
+var checksumPattern = regexp.MustCompile(`^(?:[0-9a-fA-F]{32}|[0-9a-fA-F]{40}|[0-9a-fA-F]{64}|[0-9a-fA-F]{128}|[A-Za-z0-9+/]{22}==|[A-Za-z0-9+/]{27}=|[A-Za-z0-9+/]{43}=|(?:[0-9A-Fa-f]{2}:){19,31}[0-9A-Fa-f]{2})$`)
+
+func looksLikeChecksum(value string) bool {
+	return checksumPattern.MatchString(strings.TrimSpace(value))
+}
+
+func readXML(path string, v any) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return xml.Unmarshal(content, v)
+}
+
+// loadPublicIDs maps type/name of every resource in public.xml to its ID.
+func loadPublicIDs(decodedDir string) (map[string]string, error) {
+	ids := make(map[string]string)
+	var public publicXML
+	if err := readXML(filepath.Join(decodedDir, "res", "values", "public.xml"), &public); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("could not parse public.xml: %w", err)
+	}
+	for _, entry := range public.Entries {
+		ids[entry.Type+"/"+entry.Name] = entry.ID
+	}
+	return ids, nil
+}
+
+// loadStringResources returns the default string resources of the decoded
+// APK, with their IDs.
+func loadStringResources(decodedDir string, ids map[string]string) ([]ResourceEntry, error) {
+	var values stringsXML
+	if err := readXML(filepath.Join(decodedDir, "res", "values", "strings.xml"), &values); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("could not parse strings.xml: %w", err)
+	}
+	var entries []ResourceEntry
+	for _, str := range values.Strings {
+		entries = append(entries, ResourceEntry{Type: "string", Name: str.Name, ID: ids["string/"+str.Name], Value: strings.TrimSpace(str.Value)})
+	}
+	return entries, nil
+}
+
+// LoadChecksumResources indexes string, raw and asset resources of the
+// decoded APK whose value looks like a digest, keyed by every form a smali
+// method can reference them by: resource ID, R field and asset file name.
+// Symbolic links below assets/ are followed as by Walk.
+func LoadChecksumResources(decodedDir string, followSymlinks bool) (map[string]ResourceEntry, error) {
+	checksums := make(map[string]ResourceEntry)
+	ids, err := loadPublicIDs(decodedDir)
+	if err != nil {
+		return nil, err
+	}
+
+	add := func(entry ResourceEntry) {
+		entry.ID = ids[entry.Type+"/"+entry.Name]
+		checksums["R$"+entry.Type+";->"+entry.Name] = entry
+		if entry.ID != "" {
+			checksums[strings.ToLower(entry.ID)] = entry
+		}
+	}
+
+	stringResources, err := loadStringResources(decodedDir, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range stringResources {
+		if looksLikeChecksum(entry.Value) {
+			add(entry)
+		}
+	}
+
+	raws, _ := filepath.Glob(filepath.Join(decodedDir, "res", "raw", "*"))
+	for _, raw := range raws {
+		if value, ok := readChecksumFile(raw); ok {
+			name := strings.TrimSuffix(filepath.Base(raw), filepath.Ext(raw))
+			add(ResourceEntry{Type: "raw", Name: name, Value: value})
+		}
+	}
+
+	assetsDir := filepath.Join(decodedDir, "assets")
+	Walk(assetsDir, followSymlinks, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		if value, ok := readChecksumFile(path); ok {
+			relativePath, _ := filepath.Rel(assetsDir, path)
+			relativePath = filepath.ToSlash(relativePath)
+			checksums["asset:"+relativePath] = ResourceEntry{Type: "asset", Name: relativePath, Value: value}
+		}
+		return nil
+	})
+
+	return checksums, nil
+}
+
+func readChecksumFile(path string) (string, bool) {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() > 1024 {
+		return "", false
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	value := strings.TrimSpace(string(content))
+	return value, looksLikeChecksum(value)
+}
+
+var (
+	resourceIDPattern    = regexp.MustCompile(`const(?:/high16)?\s+[vp]\d+, (0x7f[0-9a-f]{6})\b`)
+	resourceFieldPattern = regexp.MustCompile(`(R\$\w+;->[\w$]+):I`)
+)
+
+// ResourceIntegrityDetector returns a detector for methods that reference
+// the checksum resources found by LoadChecksumResources.
+func ResourceIntegrityDetector(checksums map[string]ResourceEntry) StructuralDetector {
+	// What the detector matches depends on the checksum resources of the
+	// app, so they are its fingerprint in the snapshot cache key.
+	var fingerprint []string
+	for reference, entry := range checksums {
+		fingerprint = append(fingerprint, reference+"="+entry.String()+"="+entry.Value)
+	}
+	slices.Sort(fingerprint)
+
+	return StructuralDetector{
+		ID:          "resource_integrity",
+		Name:        "Resource Integrity Check",
+		Description: "comparing against checksums embedded in resources",
+		TargetLabel: "Resources referenced",
+		Weight:      2,
+		Detect: func(methodContent string) ([]string, bool) {
+			var references []string
+			for _, match := range resourceIDPattern.FindAllStringSubmatch(methodContent, -1) {
+				references = append(references, match[1])
+			}
+			for _, match := range resourceFieldPattern.FindAllStringSubmatch(methodContent, -1) {
+				references = append(references, match[1])
+			}
+			for _, literal := range ConstStrings(methodContent) {
+				references = append(references, "asset:"+strings.TrimPrefix(literal, "file:///android_asset/"))
+			}
+
+			var targets []string
+			seen := make(map[string]struct{})
+			for _, reference := range references {
+				entry, ok := checksums[reference]
+				if !ok {
+					continue
+				}
+				target := entry.String()
+				if _, dup := seen[target]; dup {
+					continue
+				}
+				seen[target] = struct{}{}
+				targets = append(targets, target)
+			}
+			return targets, len(targets) > 0
+		},
+		Fingerprint: strings.Join(fingerprint, "\n"),
+	}
+}
+
+// ResourcesCategory is the summary row of the string resource matches.
+var ResourcesCategory = KeywordCategory{ID: "resources", Name: "String Resources", Severity: "low"}
+
+// ResourceMatch is a string resource whose value holds keywords.
+type ResourceMatch struct {
+	Resource string   `json:"resource"`
+	ID       string   `json:"id,omitempty"`
+	Value    string   `json:"value"`
+	Keywords []string `json:"keywords"`
+}
+
+// SearchStringResources matches the keywords of s against the values of the
+// string resources in res/values/strings.xml, in the matching mode of s as
+// for smali, and resolves their IDs from public.xml.
+func (s *Scanner) SearchStringResources(decodedDir string) ([]ResourceMatch, error) {
+	ids, err := loadPublicIDs(decodedDir)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := loadStringResources(decodedDir, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []ResourceMatch
+	for _, entry := range entries {
+		var found []string
+		for _, keyword := range s.keywords {
+			if s.ContainsKeyword(entry.Value, keyword) {
+				found = append(found, keyword)
+			}
+		}
+		if len(found) > 0 {
+			matches = append(matches, ResourceMatch{Resource: entry.Type + "/" + entry.Name, ID: entry.ID, Value: entry.Value, Keywords: found})
+		}
+	}
+	return matches, nil
+}
+
+// ResourcesSummary counts the string resources and distinct keywords found,
+// for the summary table.
+func ResourcesSummary(matches []ResourceMatch) CategorySummary {
+	var keywords []string
+	for _, match := range matches {
+		for _, keyword := range match.Keywords {
+			if !slices.Contains(keywords, keyword) {
+				keywords = append(keywords, keyword)
+			}
+		}
+	}
+	return CategorySummary{ID: ResourcesCategory.ID, Category: ResourcesCategory.Name, Methods: len(matches), Keywords: len(keywords), Severity: ResourcesCategory.Severity}
+}
+
+// WithResourceChecksums returns a Scanner that also runs the
+// ResourceIntegrityDetector of the checksum resources of the decoded APK in
+// decodedDir.
+func (s *Scanner) WithResourceChecksums(decodedDir string) (*Scanner, error) {
+	checksums, err := LoadChecksumResources(decodedDir, s.opts.FollowSymlinks)
+	if err != nil {
+		return nil, err
+	}
+	opts := s.opts
+	opts.Detectors = append(slices.Clip(opts.Detectors), ResourceIntegrityDetector(checksums))
+	return New(opts)
+}
@@ -0,0 +1,161 @@
+package scanner
+
+import "regexp"
+
+type Detection struct {
+	Detector string   `json:"detector"`
+	Weight   int      `json:"weight"`
+	Targets  []string `json:"targets,omitempty"`
+}
+
+// KeywordMatch locates a keyword hit in the decoded smali: the file, the
+// 1-based line it was first found on within the method and that line.
+// Matches in a string split across instructions (see Options.Reassemble)
+// are reported on the line of its first piece, with the reassembled string
+// as context. With Options.ContextLines, Snippet holds the lines of the
+// method around the hit, starting at line SnippetStart.
+type KeywordMatch struct {
+	Keyword      string   `json:"keyword"`
+	File         string   `json:"file"`
+	Line         int      `json:"line"`
+	Context      string   `json:"context"`
+	Snippet      []string `json:"snippet,omitempty"`
+	SnippetStart int      `json:"snippet_start,omitempty"`
+}
+
+type Finding struct {
+	Method     string              `json:"method"`
+	Class      string              `json:"class"`
+	SmaliPath  string              `json:"smali_path"`
+	Keywords   []string            `json:"keywords,omitempty"`
+	Matches    []KeywordMatch      `json:"matches,omitempty"`
+	Categories map[string][]string `json:"categories,omitempty"`
+	Detections []Detection         `json:"detections,omitempty"`
+	Score      int                 `json:"score"`
+}
+
+// ClassMatch holds the keywords found in a class outside its methods: in
+// field declarations and class-level annotations, see Options.ClassMetadata.
+type ClassMatch struct {
+	Class      string              `json:"class"`
+	SmaliPath  string              `json:"smali_path"`
+	Keywords   []string            `json:"keywords"`
+	Matches    []KeywordMatch      `json:"matches"`
+	Categories map[string][]string `json:"categories,omitempty"`
+}
+
+// SkippedFile is a file that could not be read or parsed and was left out
+// of the scan.
+type SkippedFile struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+type CategorySummary struct {
+	ID       string `json:"id"`
+	Category string `json:"category"`
+	Methods  int    `json:"methods"`
+	Keywords int    `json:"keywords"`
+	Severity string `json:"severity"`
+}
+
+type SmaliResults struct {
+	Findings     []Finding     `json:"findings"`
+	ClassMatches []ClassMatch  `json:"class_matches,omitempty"`
+	Skipped      []SkippedFile `json:"skipped,omitempty"`
+}
+
+func NewSmaliResults() *SmaliResults {
+	return &SmaliResults{}
+}
+
+func (r *SmaliResults) Merge(other *SmaliResults) {
+	r.Findings = append(r.Findings, other.Findings...)
+	r.ClassMatches = append(r.ClassMatches, other.ClassMatches...)
+	r.Skipped = append(r.Skipped, other.Skipped...)
+}
+
+func (r *SmaliResults) Methods() []string {
+	methods := make([]string, 0, len(r.Findings))
+	for _, finding := range r.Findings {
+		methods = append(methods, finding.Method)
+	}
+	return methods
+}
+
+func (r *SmaliResults) Keywords() map[string][]string {
+	keywords := make(map[string][]string)
+	for _, finding := range r.Findings {
+		if len(finding.Keywords) > 0 {
+			keywords[finding.Method] = finding.Keywords
+		}
+	}
+	return keywords
+}
+
+func (r *SmaliResults) Matches() map[string][]KeywordMatch {
+	matches := make(map[string][]KeywordMatch)
+	for _, finding := range r.Findings {
+		if len(finding.Matches) > 0 {
+			matches[finding.Method] = finding.Matches
+		}
+	}
+	return matches
+}
+
+func (r *SmaliResults) Detections() map[string][]Detection {
+	detections := make(map[string][]Detection)
+	for _, finding := range r.Findings {
+		if len(finding.Detections) > 0 {
+			detections[finding.Method] = finding.Detections
+		}
+	}
+	return detections
+}
+
+// DropBelowScore clears the keywords and detections of findings scoring
+// below minScore, so they are reported as plain boolean methods.
+func (r *SmaliResults) DropBelowScore(minScore int) {
+	for i := range r.Findings {
+		finding := &r.Findings[i]
+		if finding.Score >= minScore {
+			continue
+		}
+		finding.Keywords = nil
+		finding.Matches = nil
+		finding.Categories = nil
+		finding.Detections = nil
+	}
+}
+
+func (r *SmaliResults) Scores() map[string]int {
+	scores := make(map[string]int)
+	for _, finding := range r.Findings {
+		scores[finding.Method] = max(scores[finding.Method], finding.Score)
+	}
+	return scores
+}
+
+// Ignore clears the keywords and detections of the findings whose method
+// matches one of the patterns and returns how many were suppressed.
+func (r *SmaliResults) Ignore(patterns []*regexp.Regexp) int {
+	suppressed := 0
+	for i := range r.Findings {
+		finding := &r.Findings[i]
+		if len(finding.Keywords) == 0 && len(finding.Detections) == 0 {
+			continue
+		}
+		for _, pattern := range patterns {
+			if pattern.MatchString(finding.Method) {
+				finding.Keywords = nil
+				finding.Matches = nil
+				finding.Categories = nil
+				finding.Detections = nil
+				finding.Score = 0
+				suppressed++
+				break
+			}
+		}
+	}
+	return suppressed
+}
@@ -0,0 +1,179 @@
+package scanner
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Rule is a composite matcher over the smali of a method, for checks a
+// keyword list cannot express, such as a method that both calls
+// getPackageManager and references a su path. Every condition given must
+// hold: all of ContainsAll and at least one of ContainsAny appear in the
+// method, matched case-insensitively, every Regex matches it and it invokes
+// every method of CallsMethod. A rule runs as a structural detector and is
+// reported under its own name.
+type Rule struct {
+	ID          string   `yaml:"id"`
+	Name        string   `yaml:"name"`
+	Description string   `yaml:"description"`
+	Weight      int      `yaml:"weight"`
+	ContainsAll []string `yaml:"contains-all"`
+	ContainsAny []string `yaml:"contains-any"`
+	Regex       []string `yaml:"regex"`
+	// CallsMethod are method names such as getPackageManager, or
+	// references such as Ljava/net/Socket;-><init>, matched against the
+	// invoke instructions of the method.
+	CallsMethod []string `yaml:"calls-method"`
+}
+
+// DefaultRules returns the built-in rules.
+func DefaultRules() []Rule {
+	return []Rule{
+		{
+			ID:          "su_package_check",
+			Name:        "Package Manager and su Binary Check",
+			Description: "querying the package manager and checking su binaries or root manager packages",
+			Weight:      3,
+			CallsMethod: []string{"getPackageManager"},
+			ContainsAny: []string{"/system/xbin/su", "/system/bin/su", "/sbin/su", "com.topjohnwu.magisk", "eu.chainfire.supersu"},
+		},
+		{
+			ID:          "su_exec",
+			Name:        "su Command Execution",
+			Description: "running su or which su as a process",
+			Weight:      3,
+			Regex:       []string{`Ljava/lang/Runtime;->exec\(|Ljava/lang/ProcessBuilder;-><init>\(`, `const-string(?:/jumbo)? [vp]\d+, "(?:which )?su"`},
+		},
+		{
+			ID:          "tracer_pid",
+			Name:        "TracerPid Check",
+			Description: "reading TracerPid from /proc/self/status to detect a debugger",
+			Weight:      3,
+			ContainsAll: []string{"/proc/self/status", "TracerPid"},
+		},
+		{
+			ID:          "frida_port_probe",
+			Name:        "Frida Port Probe",
+			Description: "connecting to the default Frida server port",
+			Weight:      3,
+			CallsMethod: []string{"Ljava/net/InetSocketAddress;-><init>"},
+			ContainsAny: []string{"27042", "0x69a2"},
+		},
+	}
+}
+
+// Detector compiles the rule into a structural detector.
+func (r Rule) Detector() (StructuralDetector, error) {
+	if r.ID == "" || r.Name == "" {
+		return StructuralDetector{}, fmt.Errorf("rule %q: id and name are required", cmp.Or(r.ID, r.Name))
+	}
+	if len(r.ContainsAll)+len(r.ContainsAny)+len(r.Regex)+len(r.CallsMethod) == 0 {
+		return StructuralDetector{}, fmt.Errorf("rule %q has no conditions", r.ID)
+	}
+	if r.Weight < 0 {
+		return StructuralDetector{}, fmt.Errorf("rule %q has a negative weight", r.ID)
+	}
+	var patterns []*regexp.Regexp
+	for _, expression := range r.Regex {
+		pattern, err := regexp.Compile(expression)
+		if err != nil {
+			return StructuralDetector{}, fmt.Errorf("rule %q: invalid regex %q: %v", r.ID, expression, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+
+	description := r.Description
+	if description == "" {
+		description = "matching the rule " + r.Name
+	}
+	return StructuralDetector{
+		ID:          r.ID,
+		Name:        r.Name,
+		Description: description,
+		TargetLabel: "Matched",
+		Weight:      r.Weight,
+		Detect: func(methodContent string) ([]string, bool) {
+			return r.match(methodContent, patterns)
+		},
+		Fingerprint: fmt.Sprintf("%#v", r),
+	}, nil
+}
+
+// match returns what each condition matched, in rule order, when they all
+// hold.
+func (r Rule) match(methodContent string, patterns []*regexp.Regexp) ([]string, bool) {
+	var targets []string
+	add := func(target string) {
+		if !slices.Contains(targets, target) {
+			targets = append(targets, target)
+		}
+	}
+
+	lower := strings.ToLower(methodContent)
+	for _, value := range r.ContainsAll {
+		if !strings.Contains(lower, strings.ToLower(value)) {
+			return nil, false
+		}
+		add(value)
+	}
+	if len(r.ContainsAny) > 0 {
+		found := false
+		for _, value := range r.ContainsAny {
+			if strings.Contains(lower, strings.ToLower(value)) {
+				found = true
+				add(value)
+			}
+		}
+		if !found {
+			return nil, false
+		}
+	}
+	for _, pattern := range patterns {
+		location := pattern.FindStringIndex(methodContent)
+		if location == nil {
+			return nil, false
+		}
+		// A pattern such as (?m)^$ can match the empty string; the
+		// expression is then reported in place of the match.
+		add(cmp.Or(strings.TrimSpace(methodContent[location[0]:location[1]]), pattern.String()))
+	}
+	for _, method := range r.CallsMethod {
+		if !invokesMethod(methodContent, method) {
+			return nil, false
+		}
+		add(method)
+	}
+	return targets, true
+}
+
+// invokesMethod reports whether an invoke instruction of methodContent calls
+// method, a bare method name or a Class;->name reference.
+func invokesMethod(methodContent, method string) bool {
+	reference := method
+	if !strings.Contains(method, "->") {
+		reference = "->" + method
+	}
+	for _, line := range strings.Split(methodContent, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "invoke-") && strings.Contains(line, reference+"(") {
+			return true
+		}
+	}
+	return false
+}
+
+// RuleDetectors compiles rules into structural detectors.
+func RuleDetectors(rules []Rule) ([]StructuralDetector, error) {
+	var detectors []StructuralDetector
+	for _, rule := range rules {
+		detector, err := rule.Detector()
+		if err != nil {
+			return nil, err
+		}
+		detectors = append(detectors, detector)
+	}
+	return detectors, nil
+}
@@ -0,0 +1,95 @@
+package scanner
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+const ruleTestMethod = `.method public isRooted()Z
+    .locals 2
+
+    invoke-virtual {p0}, Landroid/content/Context;->getPackageManager()Landroid/content/pm/PackageManager;
+
+    const-string v0, "/system/xbin/su"
+
+    const-string v1, "/proc/self/status"
+
+    invoke-static {v0}, Lcom/app/Util;->exists(Ljava/lang/String;)Z
+
+    move-result v0
+
+    return v0
+.end method
+`
+
+func TestRuleMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    Rule
+		want    []string
+		matched bool
+	}{
+		{"contains-all", Rule{ContainsAll: []string{"/SYSTEM/xbin/su", "/proc/self/status"}}, []string{"/SYSTEM/xbin/su", "/proc/self/status"}, true},
+		{"contains-all missing one", Rule{ContainsAll: []string{"/system/xbin/su", "TracerPid"}}, nil, false},
+		{"contains-any", Rule{ContainsAny: []string{"/sbin/su", "/system/xbin/su", "/proc/self/status"}}, []string{"/system/xbin/su", "/proc/self/status"}, true},
+		{"contains-any none", Rule{ContainsAny: []string{"/sbin/su", "magisk"}}, nil, false},
+		{"regex", Rule{Regex: []string{`const-string v\d, "/system/\w+/su"`}}, []string{`const-string v0, "/system/xbin/su"`}, true},
+		{"regex no match", Rule{Regex: []string{`Runtime;->exec\(`}}, nil, false},
+		{"regex empty match", Rule{Regex: []string{`(?m)^$`}}, []string{`(?m)^$`}, true},
+		{"calls-method name", Rule{CallsMethod: []string{"getPackageManager"}}, []string{"getPackageManager"}, true},
+		{"calls-method reference", Rule{CallsMethod: []string{"Lcom/app/Util;->exists"}}, []string{"Lcom/app/Util;->exists"}, true},
+		{"calls-method only in a string", Rule{CallsMethod: []string{"status"}}, nil, false},
+		{"all conditions", Rule{
+			ContainsAll: []string{"/proc/self/status"},
+			ContainsAny: []string{"/system/xbin/su"},
+			Regex:       []string{`move-result v0`},
+			CallsMethod: []string{"getPackageManager"},
+		}, []string{"/proc/self/status", "/system/xbin/su", "move-result v0", "getPackageManager"}, true},
+		{"one condition fails", Rule{
+			ContainsAny: []string{"/system/xbin/su"},
+			CallsMethod: []string{"exec"},
+		}, nil, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.rule.ID, test.rule.Name = "test", "Test"
+			detector, err := test.rule.Detector()
+			if err != nil {
+				t.Fatal(err)
+			}
+			targets, matched := detector.Detect(ruleTestMethod)
+			if matched != test.matched || !slices.Equal(targets, test.want) {
+				t.Errorf("Detect() = %q, %v, want %q, %v", targets, matched, test.want, test.matched)
+			}
+		})
+	}
+}
+
+func TestRuleDetectorErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+		want string
+	}{
+		{"no id", Rule{Name: "Test", ContainsAll: []string{"su"}}, "id and name are required"},
+		{"no name", Rule{ID: "test", ContainsAll: []string{"su"}}, "id and name are required"},
+		{"no conditions", Rule{ID: "test", Name: "Test"}, "has no conditions"},
+		{"negative weight", Rule{ID: "test", Name: "Test", Weight: -1, ContainsAll: []string{"su"}}, "has a negative weight"},
+		{"invalid regex", Rule{ID: "test", Name: "Test", Regex: []string{"("}}, "invalid regex"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := test.rule.Detector()
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("Detector() error = %v, want one containing %q", err, test.want)
+			}
+		})
+	}
+
+	if _, err := RuleDetectors(DefaultRules()); err != nil {
+		t.Errorf("default rules: %v", err)
+	}
+}
@@ -0,0 +1,639 @@
+// Package scanner finds the boolean methods of a decoded APK's smali code
+// and matches them against keyword categories and structural detectors that
+// point at root, emulator, hooking and tampering checks. It also searches
+// native libraries for the same keywords.
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Options selects what a Scanner looks for and how keywords are matched.
+type Options struct {
+	// Categories are the keyword categories to search for, see
+	// DefaultCategories; a keyword starting with RegexKeywordPrefix is a
+	// regular expression.
+	Categories []KeywordCategory
+	// Detectors are the structural detectors to run, see DefaultDetectors.
+	Detectors []StructuralDetector
+	// DescriptorFormat reports methods as smali descriptors
+	// (Lcom/app/Foo;->isRooted()Z) instead of com.app.Foo.isRooted().
+	DescriptorFormat bool
+	// MatchArgs also reports boolean methods taking arguments and methods
+	// returning java.lang.Boolean.
+	MatchArgs bool
+	// Packages limits the scan to the classes of some packages.
+	Packages PackageFilter
+	// ReturnTypes are the return type descriptors of the methods to scan,
+	// e.g. Z, V or Ljava/lang/String;; nil scans boolean methods only.
+	ReturnTypes []string
+	// Workers is the number of smali files scanned in parallel; 0 uses one
+	// per CPU.
+	Workers       int
+	CaseSensitive bool
+	// WordBoundary only matches keywords delimited by non-identifier
+	// characters, so su does not match subscribe.
+	WordBoundary bool
+	// LiteralsOnly only matches keywords inside const-string literals.
+	LiteralsOnly bool
+	// Reassemble also matches keywords against strings an obfuscator split
+	// across instructions: a method's const-string literals joined together
+	// and the chars it builds from const values with aput-char or
+	// StringBuilder.append(C).
+	Reassemble bool
+	// ClassMetadata also matches keywords against the field declarations
+	// and class-level annotations of each class, which method bodies do not
+	// include, reporting them in SmaliResults.ClassMatches.
+	ClassMetadata bool
+	// ContextLines fills KeywordMatch.Snippet with up to this many lines of
+	// the method before and after each hit in a method, like grep -C.
+	ContextLines int
+	// StringsTool is the path of a strings(1) binary. When set, .so files
+	// are matched line by line against its output instead of being parsed
+	// in process; files it fails on are still parsed in process.
+	StringsTool string
+	// ABIs limits the .so search to the lib/<abi> directories named, e.g.
+	// arm64-v8a; nil searches every ABI.
+	ABIs []string
+	// AllABIs searches every copy of a library shipped for several ABIs
+	// instead of only the one for the most preferred ABI.
+	AllABIs bool
+	// FollowSymlinks walks into symbolic links to directories in the smali
+	// and lib directories, see Walk.
+	FollowSymlinks bool
+	// GroupABIs reports the copies of a library shipped for several ABIs
+	// as one entry, keyed by library name, listing the ABIs that matched in
+	// NativeResults.ABIs.
+	GroupABIs bool
+	// MaxFileSize skips .so files larger than this many bytes, such as ML
+	// models shipped as libraries, listing them in NativeResults.Skipped;
+	// 0 searches every file.
+	MaxFileSize int64
+	// OnFinding, when set, is called with every scored finding as soon as
+	// its smali file is scanned, from the scanning goroutines, so it must be
+	// safe for concurrent use. Findings come in no particular order.
+	OnFinding func(Finding)
+}
+
+// DefaultOptions returns the built-in categories and detectors with
+// case-insensitive substring matching.
+func DefaultOptions() Options {
+	return Options{Categories: DefaultCategories(), Detectors: DefaultDetectors()}
+}
+
+// Progress receives progress updates from long-running scans.
+type Progress interface {
+	Count(label string, total int)
+	Increment()
+}
+
+// Cache lets FindBooleanMethodsInSmali reuse the results of smali files that
+// did not change since an earlier scan. Files are identified by their
+// reported smali path and the SHA-256 of their content.
+type Cache interface {
+	Lookup(smaliPath, hash string) (*SmaliResults, bool)
+	Store(smaliPath, hash string, results *SmaliResults)
+}
+
+// Scanner matches smali methods and native libraries against a fixed set of
+// keyword categories and detectors. It is safe for concurrent use.
+type Scanner struct {
+	opts          Options
+	keywords      []string
+	patterns      keywordPatterns
+	methodPattern *regexp.Regexp
+}
+
+// keywordPatterns holds compiled regex keywords, keyed by the keyword as
+// written.
+type keywordPatterns map[string]*regexp.Regexp
+
+// keywordMatcher matches keywords against a value as the CaseSensitive and
+// WordBoundary options ask, like SearchKeywordsInMethod does per line.
+type keywordMatcher struct {
+	patterns      keywordPatterns
+	caseSensitive bool
+	wordBoundary  bool
+}
+
+// contains reports whether value holds a literal keyword or matches a regex
+// keyword.
+func (m keywordMatcher) contains(value, keyword string) bool {
+	if pattern, ok := m.patterns[keyword]; ok {
+		return pattern.MatchString(value)
+	}
+	if !m.caseSensitive {
+		value, keyword = strings.ToLower(value), strings.ToLower(keyword)
+	}
+	return KeywordIndex(value, keyword, m.wordBoundary) >= 0
+}
+
+func (s *Scanner) matcher(patterns keywordPatterns) keywordMatcher {
+	return keywordMatcher{patterns: patterns, caseSensitive: s.opts.CaseSensitive, wordBoundary: s.opts.WordBoundary}
+}
+
+// New returns a Scanner for opts, or an error when a regex keyword does not
+// compile.
+func New(opts Options) (*Scanner, error) {
+	keywords := CategoryKeywordUnion(opts.Categories)
+	patterns, err := CompileKeywordPatterns(keywords, opts.CaseSensitive)
+	if err != nil {
+		return nil, err
+	}
+	methodPattern, err := compileMethodPattern(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Scanner{opts: opts, keywords: keywords, patterns: patterns, methodPattern: methodPattern}, nil
+}
+
+func (s *Scanner) Options() Options {
+	return s.opts
+}
+
+// Keywords returns the keywords of all categories, each once.
+func (s *Scanner) Keywords() []string {
+	return s.keywords
+}
+
+// Pattern returns the compiled expression of a regex keyword.
+func (s *Scanner) Pattern(keyword string) (*regexp.Regexp, bool) {
+	pattern, ok := s.patterns[keyword]
+	return pattern, ok
+}
+
+// ContainsKeyword reports whether value holds a literal keyword, matched as
+// the CaseSensitive and WordBoundary options ask, or matches a regex keyword.
+func (s *Scanner) ContainsKeyword(value, keyword string) bool {
+	return s.matcher(s.patterns).contains(value, keyword)
+}
+
+// SearchKeywordsInMethod returns the keywords found in a method together
+// with the smali line each was first found on; firstLine is the line number
+// of the method's first line in its smali file.
+func (s *Scanner) SearchKeywordsInMethod(methodContent string, firstLine int) ([]KeywordMatch, bool) {
+	matches := []KeywordMatch{}
+	found := make(map[string]bool)
+
+	lines := strings.Split(methodContent, "\n")
+	searchable := make([]string, len(lines))
+	for i, line := range lines {
+		if s.opts.LiteralsOnly {
+			line = strings.Join(ConstStrings(line), "\n")
+		}
+		if !s.opts.CaseSensitive {
+			line = strings.ToLower(line)
+		}
+		searchable[i] = line
+	}
+
+	for _, keyword := range s.keywords {
+		needle := keyword
+		if !s.opts.CaseSensitive {
+			needle = strings.ToLower(keyword)
+		}
+
+		lineIndex := slices.IndexFunc(searchable, func(text string) bool {
+			if pattern, ok := s.patterns[keyword]; ok {
+				return pattern.MatchString(text)
+			}
+			return KeywordIndex(text, needle, s.opts.WordBoundary) >= 0
+		})
+		if lineIndex < 0 {
+			continue
+		}
+		found[keyword] = true
+
+		if slices.Contains(pathPrefixKeywords, keyword) {
+			for _, path := range probedPaths(methodContent, keyword) {
+				matches = append(matches, s.locateKeyword(lines, firstLine, path))
+			}
+			continue
+		}
+		matches = append(matches, s.keywordMatchAt(lines, firstLine, lineIndex, keyword))
+	}
+	if s.opts.Reassemble {
+		matches = append(matches, s.searchReassembled(lines, firstLine, found)...)
+	}
+
+	return matches, len(matches) > 0
+}
+
+func isIdentifierByte(b byte) bool {
+	return b == '_' || b == '$' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= 0x80
+}
+
+// KeywordIndex returns the index of the first occurrence of keyword in text,
+// or -1. With wordBoundary, only occurrences not preceded or followed by an
+// identifier character count, so "su" no longer matches "subscribe".
+func KeywordIndex(text, keyword string, wordBoundary bool) int {
+	if !wordBoundary {
+		return strings.Index(text, keyword)
+	}
+	for offset := 0; ; {
+		index := strings.Index(text[offset:], keyword)
+		if index < 0 || keyword == "" {
+			return -1
+		}
+		start, end := offset+index, offset+index+len(keyword)
+		if (start == 0 || !isIdentifierByte(text[start-1]) || !isIdentifierByte(keyword[0])) &&
+			(end == len(text) || !isIdentifierByte(text[end]) || !isIdentifierByte(keyword[len(keyword)-1])) {
+			return start
+		}
+		offset = start + 1
+	}
+}
+
+const keywordContextLength = 120
+
+func (s *Scanner) locateKeyword(lines []string, firstLine int, keyword string) KeywordMatch {
+	lowerKeyword := strings.ToLower(keyword)
+	for i, line := range lines {
+		if strings.Contains(strings.ToLower(line), lowerKeyword) {
+			return s.keywordMatchAt(lines, firstLine, i, keyword)
+		}
+	}
+	return KeywordMatch{Keyword: keyword}
+}
+
+func (s *Scanner) keywordMatchAt(lines []string, firstLine, lineIndex int, keyword string) KeywordMatch {
+	line := lines[lineIndex]
+	context := strings.TrimSpace(line)
+	if len(context) > keywordContextLength {
+		index := strings.Index(strings.ToLower(line), strings.ToLower(keyword))
+		if pattern, ok := s.patterns[keyword]; ok {
+			if location := pattern.FindStringIndex(line); location != nil {
+				index = location[0]
+			}
+		}
+		index = max(0, index)
+		start := max(0, index-keywordContextLength/2)
+		end := min(len(line), start+keywordContextLength)
+		context = "..." + strings.TrimSpace(strings.ToValidUTF8(line[start:end], "")) + "..."
+	}
+	return KeywordMatch{Keyword: keyword, Line: firstLine + lineIndex, Context: context}
+}
+
+// categorize groups the keywords found in a method by category ID.
+func (s *Scanner) categorize(keywords []string) map[string][]string {
+	categories := make(map[string][]string)
+	for _, category := range s.opts.Categories {
+		for _, keyword := range keywords {
+			for _, categoryKeyword := range category.Keywords {
+				if KeywordInCategory(keyword, categoryKeyword) {
+					categories[category.ID] = append(categories[category.ID], keyword)
+				}
+			}
+		}
+	}
+	if len(categories) == 0 {
+		return nil
+	}
+	return categories
+}
+
+var returnTypePattern = regexp.MustCompile(`^\[*(?:[ZBCSIJFDV]|L[\w/$]+;)$`)
+
+// ParseReturnTypes splits a comma-separated list of return type descriptors,
+// such as Z,V,I, and reports the first one that is not a valid descriptor.
+func ParseReturnTypes(value string) ([]string, error) {
+	var returnTypes []string
+	for _, returnType := range strings.Split(value, ",") {
+		if returnType = strings.TrimSpace(returnType); returnType == "" {
+			continue
+		}
+		if !returnTypePattern.MatchString(returnType) {
+			return nil, fmt.Errorf("unknown return type %q (expected a type descriptor such as Z, V, I or Ljava/lang/String;)", returnType)
+		}
+		if !slices.Contains(returnTypes, returnType) {
+			returnTypes = append(returnTypes, returnType)
+		}
+	}
+	return returnTypes, nil
+}
+
+// compileMethodPattern returns the expression matching the .method lines of
+// the methods to scan: those taking no parameters and returning one of
+// opts.ReturnTypes. With MatchArgs, methods taking parameters and methods
+// returning a boxed java.lang.Boolean are matched as well. The method name is
+// any smali identifier after the access flags, including $, digits-only and
+// non-ASCII names left by obfuscators; <init> and <clinit> are not matched.
+func compileMethodPattern(opts Options) (*regexp.Regexp, error) {
+	returnTypes, err := ParseReturnTypes(strings.Join(opts.ReturnTypes, ","))
+	if err != nil {
+		return nil, err
+	}
+	if len(returnTypes) == 0 {
+		returnTypes = []string{"Z"}
+	}
+
+	var alternatives []string
+	for _, returnType := range returnTypes {
+		alternatives = append(alternatives, regexp.QuoteMeta(returnType))
+	}
+
+	parameters := `\(\)`
+	if opts.MatchArgs {
+		parameters = `\([^)]*\)`
+		if slices.Contains(returnTypes, "Z") && !slices.Contains(returnTypes, "Ljava/lang/Boolean;") {
+			alternatives = append(alternatives, regexp.QuoteMeta("Ljava/lang/Boolean;"))
+		}
+	}
+	return regexp.MustCompile(`^\s*\.method\s+(?:[\w-]+\s+)*([^\s(<][^\s(]*)(` + parameters + `(?:` + strings.Join(alternatives, "|") + `))\s*$`), nil
+}
+
+// ScanSmali reads one smali file and returns a finding for each of its
+// boolean methods, with the keywords and structural detections it matched.
+// classPath is the class in slash form (com/app/Foo) and smaliPath the path
+// reported for it.
+func (s *Scanner) ScanSmali(r io.Reader, classPath, smaliPath string) (*SmaliResults, error) {
+	results := NewSmaliResults()
+	methodPattern := s.methodPattern
+	endMethodPattern := regexp.MustCompile(`\.end method`)
+
+	reader := bufio.NewReaderSize(r, 1<<20)
+	var currentMethod, currentSignature string
+	var inMethod bool
+	var methodContent strings.Builder
+	var lineNumber, methodLine int
+	// With ClassMetadata, metadata collects the field and class annotation
+	// lines, with every other line left blank so line numbers still match.
+	var metadata strings.Builder
+	var inAnyMethod bool
+	var annotationDepth int
+
+	for {
+		line, err := reader.ReadString('\n')
+
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		lineNumber++
+
+		if s.opts.ClassMetadata {
+			directive := strings.TrimSpace(line)
+			switch {
+			case strings.HasPrefix(directive, ".method "):
+				inAnyMethod = true
+			case directive == ".end method":
+				inAnyMethod = false
+			case !inAnyMethod && strings.HasPrefix(directive, ".annotation "):
+				annotationDepth++
+			}
+			if !inAnyMethod && (annotationDepth > 0 || strings.HasPrefix(directive, ".field ")) {
+				metadata.WriteString(strings.TrimRight(line, "\n"))
+			}
+			metadata.WriteString("\n")
+			if !inAnyMethod && annotationDepth > 0 && directive == ".end annotation" {
+				annotationDepth--
+			}
+		}
+
+		if methodMatch := methodPattern.FindStringSubmatch(line); methodMatch != nil {
+			currentMethod = methodMatch[1]
+			currentSignature = methodMatch[2]
+			inMethod = true
+			methodLine = lineNumber
+			methodContent.Reset()
+		}
+
+		if inMethod {
+			methodContent.WriteString(line)
+		}
+
+		if inMethod && endMethodPattern.MatchString(line) {
+			inMethod = false
+			finding := Finding{
+				Method:    FormatMethodName(classPath, currentMethod, currentSignature, s.opts),
+				Class:     strings.ReplaceAll(classPath, "/", "."),
+				SmaliPath: smaliPath,
+			}
+
+			matches, found := s.SearchKeywordsInMethod(methodContent.String(), methodLine)
+			if found {
+				for i := range matches {
+					matches[i].File = smaliPath
+					finding.Keywords = append(finding.Keywords, matches[i].Keyword)
+				}
+				if s.opts.ContextLines > 0 {
+					addSnippets(matches, methodContent.String(), methodLine, s.opts.ContextLines)
+				}
+				finding.Matches = matches
+				finding.Categories = s.categorize(finding.Keywords)
+			}
+
+			finding.Detections = runStructuralDetectors(s.opts.Detectors, methodContent.String())
+			results.Findings = append(results.Findings, finding)
+		}
+	}
+
+	if s.opts.ClassMetadata {
+		if matches, found := s.SearchKeywordsInMethod(metadata.String(), 1); found {
+			match := ClassMatch{Class: strings.ReplaceAll(classPath, "/", "."), SmaliPath: smaliPath, Matches: matches}
+			for i := range matches {
+				matches[i].File = smaliPath
+				match.Keywords = append(match.Keywords, matches[i].Keyword)
+			}
+			match.Categories = s.categorize(match.Keywords)
+			results.ClassMatches = append(results.ClassMatches, match)
+		}
+	}
+
+	return results, nil
+}
+
+// addSnippets sets the snippet of each match to the lines of content, a
+// method starting at line firstLine, within contextLines of the match.
+func addSnippets(matches []KeywordMatch, content string, firstLine, contextLines int) {
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	for i := range matches {
+		index := matches[i].Line - firstLine
+		if index < 0 || index >= len(lines) {
+			continue
+		}
+		start, end := max(index-contextLines, 0), min(index+contextLines+1, len(lines))
+		for _, line := range lines[start:end] {
+			matches[i].Snippet = append(matches[i].Snippet, strings.TrimRight(line, "\r"))
+		}
+		matches[i].SnippetStart = firstLine + start
+	}
+}
+
+// FormatMethodName names a method the way findings report it, following
+// opts.DescriptorFormat and opts.MatchArgs.
+func FormatMethodName(classPath, method, signature string, opts Options) string {
+	if opts.DescriptorFormat {
+		return fmt.Sprintf("L%s;->%s%s", classPath, method, signature)
+	}
+
+	className := strings.ReplaceAll(classPath, "/", ".")
+	className = strings.ReplaceAll(className, "$", ".")
+	if opts.MatchArgs {
+		return fmt.Sprintf("%s.%s%s", className, method, signature)
+	}
+	return fmt.Sprintf("%s.%s()", className, method)
+}
+
+// ReportedSmaliPath returns path relative to the decoded APK, e.g.
+// smali_classes2/com/app/Util.smali.
+func ReportedSmaliPath(directory, path string) string {
+	relativePath, err := filepath.Rel(directory, path)
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(filepath.Join(filepath.Base(directory), relativePath))
+}
+
+// SmaliFiles lists the smali files below directory whose classes pass the
+// package filter; excluded packages are not walked at all. Subdirectories
+// that cannot be read are returned as skipped rather than aborting the walk.
+// Symbolic links are followed as by Walk.
+func SmaliFiles(directory string, packages PackageFilter, followSymlinks bool) ([]string, []SkippedFile, error) {
+	var paths []string
+	var skipped []SkippedFile
+	err := Walk(directory, followSymlinks, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if path == directory {
+				return err
+			}
+			skipped = append(skipped, SkippedFile{ReportedSmaliPath(directory, path), err.Error()})
+			return nil
+		}
+
+		relativePath, err := filepath.Rel(directory, path)
+		if err != nil {
+			return err
+		}
+		relativePath = filepath.ToSlash(relativePath)
+		if info.IsDir() {
+			if packages.skipPackage(relativePath) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(info.Name(), ".smali") && packages.Match(strings.TrimSuffix(relativePath, ".smali")) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths, skipped, err
+}
+
+// FindBooleanMethodsInSmali scans every smali file below directory, a
+// smali* directory of a decoded APK, stopping early with ctx's error when it
+// is cancelled. Files that cannot be read are recorded as skipped. cache and
+// progress may be nil.
+func (s *Scanner) FindBooleanMethodsInSmali(ctx context.Context, directory string, cache Cache, progress Progress) (*SmaliResults, error) {
+	paths, skipped, err := SmaliFiles(directory, s.opts.Packages, s.opts.FollowSymlinks)
+	if err != nil {
+		return nil, err
+	}
+	slog.Debug("scanning smali directory", "directory", directory, "files", len(paths))
+
+	workers := s.opts.Workers
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+
+	fileResults := make([]*SmaliResults, len(paths))
+	errs := make([]error, len(paths))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+				fileResults[index], errs[index] = s.scanSmaliFile(directory, paths[index], cache)
+				if s.opts.OnFinding != nil && errs[index] == nil {
+					s.Score(fileResults[index])
+					for _, finding := range fileResults[index].Findings {
+						s.opts.OnFinding(finding)
+					}
+				}
+				if progress != nil {
+					progress.Increment()
+				}
+			}
+		}()
+	}
+	for index := range paths {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- index
+	}
+	close(jobs)
+	wg.Wait()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	results := &SmaliResults{Skipped: skipped}
+	for index := range paths {
+		if errs[index] != nil {
+			slog.Debug("skipping unreadable smali file", "file", paths[index], "error", errs[index])
+			results.Skipped = append(results.Skipped, SkippedFile{ReportedSmaliPath(directory, paths[index]), errs[index].Error()})
+			continue
+		}
+		results.Merge(fileResults[index])
+	}
+	return results, nil
+}
+
+func (s *Scanner) scanSmaliFile(directory, path string, cache Cache) (*SmaliResults, error) {
+	relativePath, err := filepath.Rel(directory, path)
+	if err != nil {
+		return nil, err
+	}
+
+	classPath := filepath.ToSlash(strings.TrimSuffix(relativePath, ".smali"))
+	smaliPath := ReportedSmaliPath(directory, path)
+
+	if cache == nil {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		return s.ScanSmali(file, classPath, smaliPath)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])
+	fileResults, ok := cache.Lookup(smaliPath, hash)
+	if !ok {
+		fileResults, err = s.ScanSmali(bytes.NewReader(content), classPath, smaliPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	cache.Store(smaliPath, hash, fileResults)
+	return fileResults, nil
+}
@@ -0,0 +1,51 @@
+package scanner
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScanSmaliMethodNames(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		want   string
+	}{
+		{"plain", ".method public isRooted()Z", "com.app.a.isRooted()"},
+		{"obfuscated single letter", ".method public a()Z", "com.app.a.a()"},
+		{"obfuscated second letter", ".method private static b()Z", "com.app.a.b()"},
+		{"inner class style", ".method public static a$a()Z", "com.app.a.a$a()"},
+		{"digits only", ".method public 0()Z", "com.app.a.0()"},
+		{"access flags", ".method public static final synthetic declared-synchronized c()Z", "com.app.a.c()"},
+		{"unicode letter", ".method public static ǃ()Z", "com.app.a.ǃ()"},
+		{"unicode combining mark", ".method public ۥ()Z", "com.app.a.ۥ()"},
+		{"mixed unicode", ".method public ۥۤۡ$ǃ()Z", "com.app.a.ۥۤۡ$ǃ()"},
+		{"indented", "    .method public d()Z", "com.app.a.d()"},
+		{"constructor", ".method public constructor <init>()Z", ""},
+		{"parameters", ".method public e(I)Z", ""},
+		{"not boolean", ".method public f()V", ""},
+	}
+
+	scanner, err := New(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			smali := ".class public Lcom/app/a;\n.super Ljava/lang/Object;\n\n" +
+				test.method + "\n    const/4 v0, 0x1\n    return v0\n.end method\n"
+			results, err := scanner.ScanSmali(strings.NewReader(smali), "com/app/a", "smali/com/app/a.smali")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got string
+			if len(results.Findings) > 0 {
+				got = results.Findings[0].Method
+			}
+			if len(results.Findings) > 1 || got != test.want {
+				t.Errorf("methods = %v, want %q", results.Methods(), test.want)
+			}
+		})
+	}
+}
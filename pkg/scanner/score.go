@@ -0,0 +1,86 @@
+package scanner
+
+import "strings"
+
+// KeywordWeight returns how strongly a keyword points at a real check:
+// the weight set in its category if any, otherwise 3 for file paths and
+// package names, 1 for short ambiguous tokens such as su and 2 for the rest.
+func (s *Scanner) KeywordWeight(keyword string) int {
+	for _, category := range s.opts.Categories {
+		for categoryKeyword, weight := range category.Weights {
+			if KeywordInCategory(keyword, categoryKeyword) {
+				return weight
+			}
+		}
+	}
+
+	switch {
+	case strings.Contains(keyword, "/") || strings.Count(keyword, ".") >= 2:
+		return 3
+	case len(keyword) <= 3:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// Score sets the score of every finding: the weights of its keywords plus
+// the weights of its structural detections.
+func (s *Scanner) Score(results *SmaliResults) {
+	for i := range results.Findings {
+		finding := &results.Findings[i]
+		finding.Score = 0
+		for _, keyword := range finding.Keywords {
+			finding.Score += s.KeywordWeight(keyword)
+		}
+		for _, detection := range finding.Detections {
+			finding.Score += detection.Weight
+		}
+	}
+}
+
+func detectorSeverity(weight int) string {
+	switch {
+	case weight >= 3:
+		return "high"
+	case weight == 2:
+		return "medium"
+	default:
+		return "low"
+	}
+}
+
+// Summarize counts, for every category and structural detector of the
+// scanner, the methods it matched and the distinct keywords or targets.
+func (s *Scanner) Summarize(results *SmaliResults) []CategorySummary {
+	var summaries []CategorySummary
+	for _, category := range s.opts.Categories {
+		matches := FilterCategoryKeywords(category.Keywords, results.Keywords())
+		keywords := make(map[string]struct{})
+		for _, found := range matches {
+			for _, keyword := range found {
+				keywords[keyword] = struct{}{}
+			}
+		}
+		summaries = append(summaries, CategorySummary{category.ID, category.Name, len(matches), len(keywords), category.Severity})
+	}
+
+	detectionsByMethod := results.Detections()
+	for _, detector := range s.opts.Detectors {
+		methods := 0
+		targets := make(map[string]struct{})
+		for _, detections := range detectionsByMethod {
+			for _, detection := range detections {
+				if detection.Detector != detector.Name {
+					continue
+				}
+				methods++
+				for _, target := range detection.Targets {
+					targets[target] = struct{}{}
+				}
+			}
+		}
+		summaries = append(summaries, CategorySummary{detector.ID, detector.Name, methods, len(targets), detectorSeverity(detector.Weight)})
+	}
+	return summaries
+}
@@ -0,0 +1,81 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// searchWithStringsTool runs the strings(1) tool at tool over a shared
+// object and matches keywords and embedded digests against its output line
+// by line. Lines holding a C++ mangled symbol are matched demangled.
+func searchWithStringsTool(ctx context.Context, tool, path string, keywords []string, matcher keywordMatcher, digests map[string]string) ([]NativeKeywordMatch, []NativeIntegrityRef, error) {
+	cmd := exec.CommandContext(ctx, tool, "-a", "-t", "d", path)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, nil, err
+	}
+
+	found := make(map[string]NativeKeywordMatch)
+	finder := newIntegrityFinder(digests)
+	reader := bufio.NewReaderSize(stdout, 1<<16)
+	for {
+		line, err := reader.ReadString('\n')
+		if str, ok := parseStringsLine(line); ok {
+			match := NativeKeywordMatch{Source: NativeSourceString, Value: str.Value}
+			if name, ok := demangleSymbol(str.Value); ok {
+				match = symbolMatch("", str.Value, name)
+			}
+			for _, keyword := range keywords {
+				if _, done := found[keyword]; !done && matcher.contains(match.Value, keyword) {
+					match.Keyword = keyword
+					found[keyword] = match
+				}
+			}
+			finder.add(str)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			cmd.Process.Kill()
+			cmd.Wait()
+			return nil, nil, err
+		}
+	}
+	if err := cmd.Wait(); err != nil {
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		}
+		return nil, nil, fmt.Errorf("%s failed on %s: %w", tool, path, err)
+	}
+
+	var matches []NativeKeywordMatch
+	for _, keyword := range keywords {
+		if match, ok := found[keyword]; ok {
+			matches = append(matches, match)
+		}
+	}
+	return matches, finder.finish(), nil
+}
+
+// parseStringsLine parses a line of strings -t d output: the decimal offset
+// of the string followed by the string.
+func parseStringsLine(line string) (printableString, bool) {
+	offset, value, ok := strings.Cut(strings.TrimLeft(strings.TrimSuffix(line, "\n"), " "), " ")
+	if !ok {
+		return printableString{}, false
+	}
+	position, err := strconv.Atoi(offset)
+	if err != nil {
+		return printableString{}, false
+	}
+	return printableString{position, value}, true
+}
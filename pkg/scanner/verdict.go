@@ -0,0 +1,121 @@
+package scanner
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// DefaultVerdictThreshold is the number of matched methods from which a
+// category counts as present when no threshold is given for it.
+const DefaultVerdictThreshold = 3
+
+// verdictSeverityWeights is how much each category counts towards the
+// overall verdict, by severity.
+var verdictSeverityWeights = map[string]float64{"high": 3, "medium": 2, "low": 1}
+
+// VerdictThresholds is the number of matched methods from which a category
+// counts as present rather than weak, by category ID.
+type VerdictThresholds struct {
+	Default    int
+	Categories map[string]int
+}
+
+// For returns the threshold of the category with id. A zero Default stands
+// for DefaultVerdictThreshold.
+func (t VerdictThresholds) For(id string) int {
+	if threshold, ok := t.Categories[id]; ok {
+		return threshold
+	}
+	if t.Default < 1 {
+		return DefaultVerdictThreshold
+	}
+	return t.Default
+}
+
+// ParseVerdictThresholds parses a comma-separated list of thresholds: a bare
+// number sets the default, id=N the threshold of one category, as in
+// "3,root=5,frida=1". Category IDs must be those of categories.
+func ParseVerdictThresholds(value string, categories []KeywordCategory) (VerdictThresholds, error) {
+	thresholds := VerdictThresholds{Default: DefaultVerdictThreshold, Categories: make(map[string]int)}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		id, number, hasID := strings.Cut(item, "=")
+		if !hasID {
+			number = id
+		}
+		threshold, err := strconv.Atoi(strings.TrimSpace(number))
+		if err != nil || threshold < 1 {
+			return thresholds, fmt.Errorf("invalid --verdict-threshold value %q: thresholds must be positive numbers", item)
+		}
+		if !hasID {
+			thresholds.Default = threshold
+			continue
+		}
+		id = strings.TrimSpace(id)
+		if !slices.ContainsFunc(categories, func(c KeywordCategory) bool { return c.ID == id }) {
+			return thresholds, fmt.Errorf("unknown --verdict-threshold category %q", id)
+		}
+		thresholds.Categories[id] = threshold
+	}
+	return thresholds, nil
+}
+
+// CategoryVerdict is the verdict on one keyword category.
+type CategoryVerdict struct {
+	ID       string `json:"id"`
+	Category string `json:"category"`
+	Methods  int    `json:"methods"`
+	// Level is absent, weak or present.
+	Level string `json:"level"`
+}
+
+// Verdict is the one-glance conclusion of a scan: how well the app covers
+// the anti-tamper checks of the keyword categories.
+type Verdict struct {
+	// Coverage is HIGH, MEDIUM, LOW or NONE.
+	Coverage   string            `json:"coverage"`
+	Categories []CategoryVerdict `json:"categories"`
+}
+
+// NewVerdict rates each of categories found in summaries as absent, weak
+// (fewer matched methods than its threshold) or present, and the app as a
+// whole by the share of categories present, weighted by severity; a weak
+// category counts half.
+func NewVerdict(summaries []CategorySummary, categories []KeywordCategory, thresholds VerdictThresholds) *Verdict {
+	verdict := &Verdict{Categories: []CategoryVerdict{}}
+	var score, total float64
+	for _, summary := range summaries {
+		if !slices.ContainsFunc(categories, func(c KeywordCategory) bool { return c.ID == summary.ID }) {
+			continue
+		}
+		category := CategoryVerdict{ID: summary.ID, Category: summary.Category, Methods: summary.Methods, Level: "absent"}
+		weight := verdictSeverityWeights[summary.Severity]
+		total += weight
+		switch {
+		case summary.Methods >= thresholds.For(summary.ID):
+			category.Level = "present"
+			score += weight
+		case summary.Methods > 0:
+			category.Level = "weak"
+			score += weight / 2
+		}
+		verdict.Categories = append(verdict.Categories, category)
+	}
+
+	switch coverage := score / max(total, 1); {
+	case coverage >= 0.6:
+		verdict.Coverage = "HIGH"
+	case coverage >= 0.3:
+		verdict.Coverage = "MEDIUM"
+	case coverage > 0:
+		verdict.Coverage = "LOW"
+	default:
+		verdict.Coverage = "NONE"
+	}
+	return verdict
+}
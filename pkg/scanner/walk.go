@@ -0,0 +1,92 @@
+package scanner
+
+import (
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// Walk walks the file tree rooted at root like filepath.Walk. By default
+// symbolic links are not followed: a link to a file is reported with the
+// link's own info and a link to a directory, root included, is not walked
+// into. With followSymlinks, links are resolved: a link to a file is
+// reported with the info of its target and a link to a directory is walked
+// like a directory, under the path of the link. Every directory is walked
+// once, under the first path it is reached by, so links to a directory
+// already walked, such as an ancestor or a sibling linking back, are
+// skipped and cycles end.
+func Walk(root string, followSymlinks bool, fn filepath.WalkFunc) error {
+	if !followSymlinks {
+		return filepath.Walk(root, fn)
+	}
+	return walkFollowing(root, fn, make(map[string]bool))
+}
+
+// walkFollowing walks root following symbolic links, recording the real
+// path of every directory it walks in visited.
+func walkFollowing(root string, fn filepath.WalkFunc, visited map[string]bool) error {
+	info, err := os.Stat(root)
+	if err != nil {
+		return fn(root, nil, err)
+	}
+	if info.IsDir() {
+		if real, err := filepath.EvalSymlinks(root); err == nil {
+			visited[real] = true
+		}
+	}
+	if err := fn(root, info, nil); err != nil || !info.IsDir() {
+		if err == filepath.SkipDir || err == filepath.SkipAll {
+			return nil
+		}
+		return err
+	}
+
+	// The trailing separator makes WalkDir resolve root when it is a link.
+	start := root + string(filepath.Separator)
+	err = filepath.WalkDir(start, func(path string, entry fs.DirEntry, err error) error {
+		if path == start {
+			return err
+		}
+		if err != nil {
+			var info fs.FileInfo
+			if entry != nil {
+				info, _ = entry.Info()
+			}
+			return fn(path, info, err)
+		}
+		if entry.Type()&fs.ModeSymlink == 0 {
+			if entry.IsDir() {
+				if real, err := filepath.EvalSymlinks(path); err == nil {
+					if visited[real] {
+						return filepath.SkipDir
+					}
+					visited[real] = true
+				}
+			}
+			info, err := entry.Info()
+			return fn(path, info, err)
+		}
+
+		target, err := os.Stat(path)
+		if err != nil {
+			link, linkErr := entry.Info()
+			if linkErr != nil {
+				return fn(path, nil, linkErr)
+			}
+			return fn(path, link, nil)
+		}
+		if !target.IsDir() {
+			return fn(path, target, nil)
+		}
+		if real, err := filepath.EvalSymlinks(path); err != nil || visited[real] {
+			slog.Debug("skipping symlink to a directory already walked", "link", path)
+			return nil
+		}
+		return walkFollowing(path, fn, visited)
+	})
+	if err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
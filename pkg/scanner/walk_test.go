@@ -0,0 +1,72 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestWalkFollowSymlinks(t *testing.T) {
+	tests := []struct {
+		name  string
+		links map[string]string
+		want  []string
+	}{
+		{
+			name:  "link to an ancestor",
+			links: map[string]string{"a/up": ".."},
+			want:  []string{"a/A.smali", "b/B.smali"},
+		},
+		{
+			name:  "siblings linked to each other",
+			links: map[string]string{"a/x": "../b", "b/y": "../a"},
+			want:  []string{"a/A.smali", "a/x/B.smali"},
+		},
+		{
+			name:  "link to a directory outside the others",
+			links: map[string]string{"a/shared": "../../shared"},
+			want:  []string{"a/A.smali", "a/shared/S.smali", "b/B.smali"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			root := filepath.Join(dir, "smali")
+			for _, file := range []string{"smali/a/A.smali", "smali/b/B.smali", "shared/S.smali"} {
+				path := filepath.Join(dir, file)
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for link, target := range test.links {
+				if err := os.Symlink(target, filepath.Join(root, link)); err != nil {
+					t.Skipf("cannot create symlinks: %v", err)
+				}
+			}
+
+			var files []string
+			err := Walk(root, true, func(path string, info os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
+				if !info.IsDir() {
+					relative, _ := filepath.Rel(root, path)
+					files = append(files, filepath.ToSlash(relative))
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			slices.Sort(files)
+			if !slices.Equal(files, test.want) {
+				t.Errorf("walked %v, want %v", files, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/briandowns/spinner"
+)
+
+// quiet disables all progress output; it is set by --quiet and --silent.
+var quiet bool
+
+// Progress shows a spinner with either a status message or an "N/M files
+// scanned" counter. A nil *Progress, returned when quiet is set, does nothing.
+type Progress struct {
+	spinner *spinner.Spinner
+	label   string
+	done    int
+	total   int
+}
+
+func newSpinner() *spinner.Spinner {
+	writer := os.Stdout
+	if console != io.Writer(os.Stdout) {
+		writer = os.Stderr
+	}
+	frames := make([]string, len(spinner.CharSets[14]))
+	for i, frame := range spinner.CharSets[14] {
+		frames[i] = green("%s", frame)
+	}
+	return spinner.New(frames, 100*time.Millisecond, spinner.WithWriterFile(writer))
+}
+
+func newProgress() *Progress {
+	if quiet {
+		return nil
+	}
+	return &Progress{spinner: newSpinner()}
+}
+
+func (p *Progress) Start() {
+	if p != nil {
+		p.spinner.Start()
+	}
+}
+
+func (p *Progress) Stop() {
+	if p != nil {
+		p.spinner.Stop()
+	}
+}
+
+// Status replaces the spinner message.
+func (p *Progress) Status(format string, args ...interface{}) {
+	if p == nil {
+		return
+	}
+	p.spinner.Lock()
+	defer p.spinner.Unlock()
+	p.spinner.Suffix = " " + fmt.Sprintf(format, args...)
+}
+
+// Count switches the spinner to a file counter for total files, labelled
+// with what is being scanned.
+func (p *Progress) Count(label string, total int) {
+	if p == nil {
+		return
+	}
+	p.spinner.Lock()
+	defer p.spinner.Unlock()
+	p.label, p.done, p.total = label, 0, total
+	p.spinner.Suffix = p.counter()
+}
+
+// Increment marks one more file as scanned. It is safe for concurrent use.
+func (p *Progress) Increment() {
+	if p == nil {
+		return
+	}
+	p.spinner.Lock()
+	defer p.spinner.Unlock()
+	p.done++
+	p.spinner.Suffix = p.counter()
+}
+
+func (p *Progress) counter() string {
+	return fmt.Sprintf(" %s: %d/%d files scanned", p.label, p.done, p.total)
+}
@@ -0,0 +1,17 @@
+package main
+
+import "fmt"
+
+func PrintProtectionSDKs(sdks []ProtectionSDK) {
+	if len(sdks) == 0 {
+		fmt.Fprintln(status, red("X No known protection SDKs found."))
+		fmt.Fprintln(status)
+		return
+	}
+
+	fmt.Fprintln(console, yellow("✔ Protection SDKs:"))
+	for _, sdk := range sdks {
+		fmt.Fprintf(console, "  %s\n", cyan("+ %s", sdk))
+	}
+	fmt.Fprintln(console)
+}
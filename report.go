@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+type Report struct {
+	Tool      string   `json:"tool"`
+	Version   string   `json:"version"`
+	APK       string   `json:"apk"`
+	Splits    []string `json:"splits,omitempty"`
+	APKSHA256 string   `json:"apk_sha256,omitempty"`
+	AppInfo
+	Framework    string              `json:"framework,omitempty"`
+	Packing      []string            `json:"packing,omitempty"`
+	Protection   []ProtectionSDK     `json:"protection,omitempty"`
+	Config       ScanConfig          `json:"config"`
+	Summary      []CategorySummary   `json:"summary"`
+	Verdict      *Verdict            `json:"verdict,omitempty"`
+	TotalMethods int                 `json:"total_methods"`
+	Findings     []Finding           `json:"findings"`
+	ClassMatches []ClassMatch        `json:"class_matches,omitempty"`
+	Native       *NativeResults      `json:"native,omitempty"`
+	EarlyInit    map[string][]string `json:"early_init,omitempty"`
+	Callers      map[string][]string `json:"callers,omitempty"`
+	Classes      []ClassSummary      `json:"classes,omitempty"`
+	AppFiles     *AppFileResults     `json:"app_files,omitempty"`
+	Resources    []ResourceMatch     `json:"resources,omitempty"`
+	Skipped      []SkippedFile       `json:"skipped,omitempty"`
+	Diff         *ReportDiff         `json:"diff,omitempty"`
+}
+
+// NewReport collects the scan results into a Report. Findings are
+// deduplicated by method name and sorted so the document is stable.
+func NewReport(apkFile string, config ScanConfig, results *SmaliResults, summaries []CategorySummary) *Report {
+	report := &Report{
+		Tool:    "boolseeker",
+		Version: version,
+		APK:     apkFile,
+		Config:  config,
+		Summary: summaries,
+	}
+
+	seen := make(map[string]struct{})
+	for _, finding := range results.Findings {
+		if _, dup := seen[finding.Method]; dup {
+			continue
+		}
+		seen[finding.Method] = struct{}{}
+		report.Findings = append(report.Findings, finding)
+	}
+	slices.SortFunc(report.Findings, func(a, b Finding) int {
+		return strings.Compare(a.Method, b.Method)
+	})
+	report.TotalMethods = len(report.Findings)
+	report.ClassMatches = results.ClassMatches
+	return report
+}
+
+func (r *Report) HasFindings() bool {
+	for _, finding := range r.Findings {
+		if len(finding.Keywords) > 0 || len(finding.Detections) > 0 {
+			return true
+		}
+	}
+	return r.Native != nil && (len(r.Native.Keywords) > 0 || len(r.Native.Integrity) > 0)
+}
+
+// WriteMethodList writes the methods to path, one per line in sorted order
+// so the file is stable across runs. It is written next to path first and
+// then renamed into place, so an interrupted run never leaves a truncated
+// file behind.
+func WriteMethodList(path string, methods []string) error {
+	methods = slices.Clone(methods)
+	slices.Sort(methods)
+
+	var content strings.Builder
+	for _, method := range methods {
+		content.WriteString(method + "\n")
+	}
+	return writeMethodFile(path, content.String())
+}
+
+// WriteMethodContext writes the methods like WriteMethodList, each followed
+// by the smali snippets of its keyword matches in grep -C style: hit lines
+// as path:line:text, context lines as path-line-text and -- between
+// separate snippets. Methods are separated by a blank line.
+func WriteMethodContext(path string, methods []string, matches map[string][]KeywordMatch) error {
+	methods = slices.Clone(methods)
+	slices.Sort(methods)
+
+	var content strings.Builder
+	for i, method := range methods {
+		if i > 0 {
+			content.WriteString("\n")
+		}
+		content.WriteString(method + "\n")
+
+		type snippetLine struct {
+			text string
+			hit  bool
+		}
+		file := ""
+		lines := make(map[int]*snippetLine)
+		for _, match := range matches[method] {
+			file = match.File
+			for offset, text := range match.Snippet {
+				if lines[match.SnippetStart+offset] == nil {
+					lines[match.SnippetStart+offset] = &snippetLine{text: text}
+				}
+			}
+			if line := lines[match.Line]; line != nil {
+				line.hit = true
+			}
+		}
+		numbers := make([]int, 0, len(lines))
+		for number := range lines {
+			numbers = append(numbers, number)
+		}
+		slices.Sort(numbers)
+		for j, number := range numbers {
+			if j > 0 && number != numbers[j-1]+1 {
+				content.WriteString("--\n")
+			}
+			separator := "-"
+			if lines[number].hit {
+				separator = ":"
+			}
+			fmt.Fprintf(&content, "%s%s%d%s%s\n", file, separator, number, separator, lines[number].text)
+		}
+	}
+	return writeMethodFile(path, content.String())
+}
+
+// writeMethodFile replaces path with content through a temporary file, so
+// an interrupted run never leaves a truncated list behind.
+func writeMethodFile(path, content string) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(content), 0644); err != nil {
+		return fmt.Errorf("could not write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("could not write %s: %w", path, err)
+	}
+	return nil
+}
+
+func marshalJSON(v any, pretty bool) ([]byte, error) {
+	if pretty {
+		return json.MarshalIndent(v, "", "  ")
+	}
+	return json.Marshal(v)
+}
+
+func WriteJSONReport(path string, report *Report, pretty bool) error {
+	return writeJSON(path, report, pretty)
+}
+
+// WriteJSONReports writes a single report as is and several as a JSON array
+// of reports.
+func WriteJSONReports(path string, reports []*Report, pretty bool) error {
+	if len(reports) == 1 {
+		return writeJSON(path, reports[0], pretty)
+	}
+	return writeJSON(path, reports, pretty)
+}
+
+func writeJSON(path string, v any, pretty bool) error {
+	content, err := marshalJSON(v, pretty)
+	if err != nil {
+		return err
+	}
+	content = append(content, '\n')
+
+	if path == "" || path == "-" {
+		_, err = os.Stdout.Write(content)
+		return err
+	}
+	return os.WriteFile(path, content, 0644)
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/0xdeny/boolseeker/pkg/scanner"
+)
+
+func PrintResourceMatches(matches []ResourceMatch) {
+	if len(matches) == 0 {
+		fmt.Fprintln(status, red("X No keywords found in string resources."))
+		fmt.Fprintln(status)
+		return
+	}
+
+	fmt.Fprintln(console, yellow("✔ String resources containing keywords (%s):", scanner.ResourcesCategory.Name))
+	for _, match := range matches {
+		resource := scanner.ResourceEntry{Type: "string", Name: strings.TrimPrefix(match.Resource, "string/"), ID: match.ID}
+		fmt.Fprintf(console, "  %s %s%s\n", cyan("+ %s", resource), white("- "), red("Keywords found: %s", strings.Join(match.Keywords, ", ")))
+		fmt.Fprintf(console, "      %q\n", match.Value)
+	}
+	fmt.Fprintln(console)
+}
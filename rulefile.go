@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/0xdeny/boolseeker/pkg/scanner"
+	"gopkg.in/yaml.v3"
+)
+
+// LoadRuleFile reads a YAML file holding a list of rules and compiles them
+// into structural detectors. A rule may not reuse the ID of a keyword
+// category or of another detector, so --categories can select it.
+func LoadRuleFile(path string) ([]StructuralDetector, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read rules file %s: %w", path, err)
+	}
+
+	var rules []Rule
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&rules); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid rules file %s: expected a list of rules: %v", path, err)
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("invalid rules file %s: no rules defined", path)
+	}
+
+	detectors, err := scanner.RuleDetectors(rules)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rules file %s: %v", path, err)
+	}
+	for i, detector := range detectors {
+		if slices.ContainsFunc(keywordCategories, func(c KeywordCategory) bool { return c.ID == detector.ID }) ||
+			slices.ContainsFunc(structuralDetectors, func(d StructuralDetector) bool { return d.ID == detector.ID }) ||
+			slices.ContainsFunc(detectors[:i], func(d StructuralDetector) bool { return d.ID == detector.ID }) {
+			return nil, fmt.Errorf("invalid rules file %s: rule %q reuses the ID of a category or detector", path, detector.ID)
+		}
+	}
+	return detectors, nil
+}
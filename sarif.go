@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool     `json:"tool"`
+	Results    []sarifResult `json:"results"`
+	Properties *AppInfo      `json:"properties,omitempty"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	Name                 string             `json:"name"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+func sarifLevel(severity string) string {
+	switch severity {
+	case "high":
+		return "error"
+	case "medium":
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// sarifRuleID turns a category name such as "Rooted Device Detection" into
+// a rule ID such as "rooted-device-detection".
+func sarifRuleID(name string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	}), "-")
+}
+
+// NewSARIFLog converts reports into a SARIF 2.1.0 log with one run per
+// report.
+func NewSARIFLog(reports []*Report) sarifLog {
+	runs := make([]sarifRun, 0, len(reports))
+	for _, report := range reports {
+		runs = append(runs, newSARIFRun(report))
+	}
+	return sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    runs,
+	}
+}
+
+// newSARIFRun converts a report into a SARIF run with one rule per keyword
+// category and structural detector, and one result per boolean method and
+// category it matched.
+func newSARIFRun(report *Report) sarifRun {
+	driver := sarifDriver{
+		Name:           "boolseeker",
+		Version:        report.Version,
+		InformationURI: "https://github.com/0xdeny/boolseeker",
+		Rules:          []sarifRule{},
+	}
+
+	ruleIndex := make(map[string]int)
+	for _, summary := range report.Summary {
+		ruleIndex[summary.ID] = len(driver.Rules)
+		ruleIndex[summary.Category] = len(driver.Rules)
+		driver.Rules = append(driver.Rules, sarifRule{
+			ID:                   sarifRuleID(summary.Category),
+			Name:                 summary.Category,
+			ShortDescription:     sarifMessage{summary.Category},
+			DefaultConfiguration: sarifConfiguration{sarifLevel(summary.Severity)},
+		})
+	}
+
+	results := []sarifResult{}
+	addResult := func(finding Finding, key, text string, line int) {
+		index, ok := ruleIndex[key]
+		if !ok {
+			return
+		}
+		rule := driver.Rules[index]
+		var region *sarifRegion
+		if line > 0 {
+			region = &sarifRegion{line}
+		}
+		results = append(results, sarifResult{
+			RuleID:    rule.ID,
+			RuleIndex: index,
+			Level:     rule.DefaultConfiguration.Level,
+			Message:   sarifMessage{fmt.Sprintf("%s: %s", finding.Method, text)},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{sarifArtifactLocation{finding.SmaliPath}, region},
+				LogicalLocations: []sarifLogicalLocation{{finding.Method, "function"}},
+			}},
+		})
+	}
+
+	for _, finding := range report.Findings {
+		categories := make([]string, 0, len(finding.Categories))
+		for id := range finding.Categories {
+			categories = append(categories, id)
+		}
+		slices.SortFunc(categories, func(a, b string) int { return ruleIndex[a] - ruleIndex[b] })
+		for _, id := range categories {
+			line := 0
+			for _, match := range finding.Matches {
+				if slices.Contains(finding.Categories[id], match.Keyword) && match.Line > 0 && (line == 0 || match.Line < line) {
+					line = match.Line
+				}
+			}
+			addResult(finding, id, "keywords found: "+strings.Join(finding.Categories[id], ", "), line)
+		}
+		for _, detection := range finding.Detections {
+			addResult(finding, detection.Detector, "targets: "+strings.Join(detection.Targets, ", "), 0)
+		}
+	}
+
+	run := sarifRun{Tool: sarifTool{driver}, Results: results}
+	if report.AppInfo != (AppInfo{}) {
+		run.Properties = &report.AppInfo
+	}
+	return run
+}
+
+func WriteSARIFReport(path string, reports []*Report, pretty bool) error {
+	content, err := marshalJSON(NewSARIFLog(reports), pretty)
+	if err != nil {
+		return err
+	}
+	content = append(content, '\n')
+
+	if path == "" || path == "-" {
+		_, err = os.Stdout.Write(content)
+		return err
+	}
+	return os.WriteFile(path, content, 0644)
+}
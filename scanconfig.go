@@ -0,0 +1,136 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/0xdeny/boolseeker/pkg/scanner"
+)
+
+type ScanConfig struct {
+	Version       string   `json:"version"`
+	KeywordsHash  string   `json:"keywords_hash"`
+	Categories    []string `json:"categories"`
+	Detectors     []string `json:"detectors"`
+	DetectorsHash string   `json:"detectors_hash"`
+	MatchingMode  string   `json:"matching_mode"`
+	MethodFormat  string   `json:"method_format"`
+	MatchArgs     bool     `json:"match_args"`
+	ReturnTypes   []string `json:"return_types"`
+	Engine        string   `json:"engine"`
+	Workers       int      `json:"workers"`
+	SearchSo      bool     `json:"search_so"`
+	ABIs          []string `json:"abis,omitempty"`
+	ClassMetadata bool     `json:"class_metadata,omitempty"`
+	ContextLines  int      `json:"context_lines,omitempty"`
+}
+
+// KeywordsHash hashes the keyword list and everything of each category that
+// ends up in a finding: its ID, name, severity, keywords and keyword weights.
+func KeywordsHash(searchKeywords []string, categories []KeywordCategory) string {
+	h := sha256.New()
+	h.Write([]byte(strings.Join(searchKeywords, "\n")))
+	for _, category := range categories {
+		h.Write([]byte("\x00" + category.ID + "\x00" + category.Name + "\x00" + category.Severity + "\x00"))
+		h.Write([]byte(strings.Join(category.Keywords, "\n")))
+		weighted := make([]string, 0, len(category.Weights))
+		for keyword := range category.Weights {
+			weighted = append(weighted, keyword)
+		}
+		slices.Sort(weighted)
+		for _, keyword := range weighted {
+			h.Write([]byte("\x00" + keyword + "=" + strconv.Itoa(category.Weights[keyword])))
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// DetectorsHash hashes the definition of each structural detector, so that
+// editing a rule changes it even when the rule keeps its name.
+func DetectorsHash(detectors []StructuralDetector) string {
+	h := sha256.New()
+	for _, detector := range detectors {
+		h.Write([]byte(detector.ID + "\x00" + detector.Name + "\x00" + strconv.Itoa(detector.Weight) + "\x00" + detector.Fingerprint + "\x00"))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// CacheKey hashes the settings that change the results of a smali file, so
+// a snapshot written under different ones is not reused.
+func (c ScanConfig) CacheKey() string {
+	h := sha256.New()
+	h.Write([]byte(c.Version + "\x00" + c.KeywordsHash + "\x00" + c.MatchingMode + "\x00" + c.MethodFormat + "\x00"))
+	h.Write([]byte(strconv.FormatBool(c.MatchArgs) + "\x00" + strings.Join(c.ReturnTypes, ",") + "\x00" + c.Engine + "\x00"))
+	h.Write([]byte(strings.Join(c.Categories, "\n") + "\x00"))
+	h.Write([]byte(strings.Join(c.Detectors, "\n") + "\x00" + c.DetectorsHash))
+	if c.ClassMetadata {
+		h.Write([]byte("\x00class-metadata"))
+	}
+	if c.ContextLines > 0 {
+		h.Write([]byte("\x00context-lines=" + strconv.Itoa(c.ContextLines)))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func EffectiveScanConfig(searchSo bool, engine string, opts scanner.Options) ScanConfig {
+	var categories []string
+	for _, category := range keywordCategories {
+		categories = append(categories, category.Name)
+	}
+
+	var detectors []string
+	for _, detector := range opts.Detectors {
+		detectors = append(detectors, detector.Name)
+	}
+
+	methodFormat := "dotted"
+	if opts.DescriptorFormat {
+		methodFormat = "descriptor"
+	}
+
+	workers := opts.Workers
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+
+	return ScanConfig{
+		Version:       version,
+		KeywordsHash:  KeywordsHash(searchKeywords, keywordCategories),
+		Categories:    categories,
+		Detectors:     detectors,
+		DetectorsHash: DetectorsHash(opts.Detectors),
+		MatchingMode:  matchingMode(opts),
+		MethodFormat:  methodFormat,
+		MatchArgs:     opts.MatchArgs,
+		ReturnTypes:   opts.ReturnTypes,
+		Engine:        engine,
+		Workers:       workers,
+		SearchSo:      searchSo,
+		ABIs:          opts.ABIs,
+		ClassMetadata: opts.ClassMetadata,
+		ContextLines:  opts.ContextLines,
+	}
+}
+
+func matchingMode(opts scanner.Options) string {
+	mode := "substring"
+	if opts.WordBoundary {
+		mode = "word"
+	}
+	if opts.CaseSensitive {
+		mode += ", case-sensitive"
+	} else {
+		mode += ", case-insensitive"
+	}
+	if opts.LiteralsOnly {
+		mode += ", string literals only"
+	}
+	if opts.Reassemble {
+		mode += ", reassembled strings"
+	}
+	return mode
+}
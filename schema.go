@@ -0,0 +1,129 @@
+package main
+
+import (
+	"io"
+	"reflect"
+	"strings"
+)
+
+// schemaGenerator builds a JSON Schema from Go types by reflection, the same
+// way encoding/json reads them, so the schema cannot drift from the output.
+// Named struct types are described once in defs and referenced.
+type schemaGenerator struct {
+	defs map[string]any
+}
+
+func (g *schemaGenerator) schema(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.schema(t.Elem())
+	case reflect.Struct:
+		if _, ok := g.defs[t.Name()]; !ok {
+			g.defs[t.Name()] = nil
+			g.defs[t.Name()] = g.object(t)
+		}
+		return map[string]any{"$ref": "#/$defs/" + t.Name()}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]any{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	default:
+		return map[string]any{}
+	}
+}
+
+// object describes a struct: its exported fields under their json names,
+// with the fields of embedded structs inlined. Fields without omitempty
+// are required, and those that encode a nil slice, map or pointer as null
+// may be null.
+func (g *schemaGenerator) object(t reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	var required []string
+	var addFields func(t reflect.Type)
+	addFields = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			tag := field.Tag.Get("json")
+			if tag == "-" || !field.IsExported() && !field.Anonymous {
+				continue
+			}
+			name, options, _ := strings.Cut(tag, ",")
+			if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+				addFields(field.Type)
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+
+			property := g.schema(field.Type)
+			omitEmpty := strings.Contains(","+options+",", ",omitempty,")
+			switch field.Type.Kind() {
+			case reflect.Pointer, reflect.Slice, reflect.Map:
+				if !omitEmpty {
+					property = map[string]any{"anyOf": []any{property, map[string]any{"type": "null"}}}
+				}
+			}
+			properties[name] = property
+			if !omitEmpty {
+				required = append(required, name)
+			}
+		}
+	}
+	addFields(t)
+
+	object := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		object["required"] = required
+	}
+	return object
+}
+
+// JSONSchema returns the JSON Schema (draft 2020-12) of the JSON encoding of
+// v's type.
+func JSONSchema(v any, title string) map[string]any {
+	g := &schemaGenerator{defs: make(map[string]any)}
+	schema := map[string]any{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title":   title,
+	}
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	for key, value := range g.object(t) {
+		schema[key] = value
+	}
+	if len(g.defs) > 0 {
+		schema["$defs"] = g.defs
+	}
+	return schema
+}
+
+// PrintSchema writes the JSON Schema of the output of --format, the report
+// by default or one line of --format ndjson.
+func PrintSchema(w io.Writer, format string) error {
+	schema := JSONSchema(&Report{}, "boolseeker report")
+	if format == "ndjson" {
+		schema = JSONSchema(&ndjsonFinding{}, "boolseeker ndjson finding")
+	}
+	content, err := marshalJSON(schema, true)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(content, '\n'))
+	return err
+}
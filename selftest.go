@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/0xdeny/boolseeker/pkg/scanner"
+)
+
+// selfTestSmali holds synthetic smali classes with one known detection
+// method each, scanned by --self-test.
+//
+//go:embed selftest
+var selfTestSmali embed.FS
+
+// selfTestCase is a boolean method of the embedded samples and the category
+// it must be found in; an empty category means no keywords may be found.
+type selfTestCase struct {
+	method   string
+	category string
+}
+
+var selfTestCases = []selfTestCase{
+	{"com.boolseeker.selftest.RootCheck.isRooted()", "root"},
+	{"com.boolseeker.selftest.EmulatorCheck.isEmulator()", "emulator"},
+	{"com.boolseeker.selftest.FridaCheck.isFridaRunning()", "frida"},
+	{"com.boolseeker.selftest.XposedCheck.isXposedLoaded()", "xposed"},
+	{"com.boolseeker.selftest.IntegrityCheck.isApkModified()", "integrity"},
+	{"com.boolseeker.selftest.DebuggerCheck.isDebuggerAttached()", "debugger"},
+	{"com.boolseeker.selftest.KeystoreCheck.isKeyAttested()", "keystore"},
+	{"com.boolseeker.selftest.VpnCheck.isVpnActive()", "vpn"},
+	{"com.boolseeker.selftest.HookCheck.isCallStackHooked()", "hooking"},
+	{"com.boolseeker.selftest.IntegrityCheck.isDebugBuild()", ""},
+}
+
+// extractSelfTestSmali writes the embedded samples to a temporary
+// directory, as the scanner reads smali from disk.
+func extractSelfTestSmali() (string, error) {
+	dir, err := os.MkdirTemp("", "boolseeker-selftest-")
+	if err != nil {
+		return "", err
+	}
+	err = fs.WalkDir(selfTestSmali, "selftest", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		data, err := selfTestSmali.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(path, "selftest/")))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0o644)
+	})
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	return dir, nil
+}
+
+// RunSelfTest scans the embedded samples with the built-in keywords and
+// checks that every expected category is found, and that apktool can be
+// found at apktoolPath. It prints one line per check and reports whether
+// all of them passed.
+func RunSelfTest(ctx context.Context, apktoolPath string) bool {
+	passed := true
+	check := func(ok bool, format string, args ...any) {
+		if ok {
+			fmt.Fprintln(console, green("✔ PASS "+format, args...))
+		} else {
+			fmt.Fprintln(console, red("✖ FAIL "+format, args...))
+			passed = false
+		}
+	}
+
+	if path, err := CheckApkTool(apktoolPath); err != nil {
+		check(false, "apktool: %s", strings.TrimPrefix(err.Error(), "✖️ "))
+	} else {
+		check(true, "apktool: %s", path)
+	}
+
+	scan, err := scanner.New(scanner.DefaultOptions())
+	if err != nil {
+		check(false, "built-in keywords: %v", err)
+		return false
+	}
+
+	snippet := "    .locals 1\n    const-string v0, \"com.topjohnwu.magisk\"\n    return v0"
+	matches, _ := scan.SearchKeywordsInMethod(snippet, 10)
+	found := slices.ContainsFunc(matches, func(match KeywordMatch) bool {
+		return match.Keyword == "com.topjohnwu.magisk" && match.Line == 11
+	})
+	check(found, "keyword matching: com.topjohnwu.magisk found on line 11")
+
+	dir, err := extractSelfTestSmali()
+	if err != nil {
+		check(false, "sample smali: %v", err)
+		return false
+	}
+	defer os.RemoveAll(dir)
+
+	results, err := scan.FindBooleanMethodsInSmali(ctx, filepath.Join(dir, "smali"), nil, nil)
+	if err != nil {
+		check(false, "boolean method scan: %v", err)
+		return false
+	}
+	findings := make(map[string]Finding)
+	for _, finding := range results.Findings {
+		findings[finding.Method] = finding
+	}
+	for _, test := range selfTestCases {
+		finding, ok := findings[test.method]
+		switch {
+		case !ok:
+			check(false, "%s: boolean method not found", test.method)
+		case test.category == "":
+			check(len(finding.Keywords) == 0, "%s: no keywords %v", test.method, finding.Keywords)
+		default:
+			keywords := finding.Categories[test.category]
+			check(len(keywords) > 0, "%s: %s keywords %v", test.method, test.category, keywords)
+		}
+	}
+	return passed
+}
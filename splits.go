@@ -0,0 +1,64 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/0xdeny/boolseeker/pkg/scanner"
+)
+
+// MergeSplit moves the code, native libraries and assets of a decoded split
+// APK into the decoded base APK, so they are scanned as one app. The split's
+// smali directories keep its name, e.g. smali_split_feature, so findings
+// point at the split they came from.
+func MergeSplit(splitDirectory, decodedDirectory, splitName string) error {
+	smaliDirs, err := scanner.FindSmaliDirs(splitDirectory, followSymlinks)
+	if err != nil {
+		return err
+	}
+	for _, smaliDir := range smaliDirs {
+		if err := os.Rename(smaliDir, filepath.Join(decodedDirectory, filepath.Base(smaliDir)+"_"+splitName)); err != nil {
+			return err
+		}
+	}
+
+	for _, subdirectory := range []string{"lib", "assets"} {
+		root := filepath.Join(splitDirectory, subdirectory)
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+
+			relativePath, err := filepath.Rel(splitDirectory, path)
+			if err != nil {
+				return err
+			}
+			target := filepath.Join(decodedDirectory, relativePath)
+			if _, err := os.Stat(target); err == nil {
+				slog.Debug("skipping file already in the base APK", "split", splitName, "file", relativePath)
+				return nil
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			return os.Rename(path, target)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return os.RemoveAll(splitDirectory)
+}
+
+func splitName(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), ".apk")
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+func isRemoteInput(input string) bool {
+	return strings.HasPrefix(input, "http://") || strings.HasPrefix(input, "https://")
+}
+
+// inputName returns the file name of an input, used to name its decoded
+// directory: "stdin" for -, and the last path segment of a URL.
+func inputName(input string) string {
+	if input == "-" {
+		return "stdin"
+	}
+	if isRemoteInput(input) {
+		if u, err := url.Parse(input); err == nil {
+			if name := path.Base(u.Path); name != "/" && name != "." {
+				return name
+			}
+		}
+		return "download"
+	}
+	return input
+}
+
+// StageInput materializes an APK read from stdin (-) or downloaded from an
+// http(s) URL into a temporary file and returns its path; the caller removes
+// it with the returned cleanup function. Local files are returned as is.
+// The download stops when ctx is cancelled or after timeout, unless timeout
+// is 0.
+func StageInput(ctx context.Context, input string, timeout time.Duration) (string, func(), error) {
+	if input != "-" && !isRemoteInput(input) {
+		return input, func() {}, nil
+	}
+
+	var src io.Reader = os.Stdin
+	if isRemoteInput(input) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, input, nil)
+		if err != nil {
+			return "", nil, fmt.Errorf("✖ Error downloading %s: %w", input, err)
+		}
+		req.Header.Set("User-Agent", "boolseeker/"+version)
+		client := &http.Client{
+			Timeout:   timeout,
+			Transport: &http.Transport{Proxy: http.ProxyFromEnvironment},
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", nil, fmt.Errorf("✖ Error downloading %s: %w", input, err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", nil, fmt.Errorf("✖ Error downloading %s: %s", input, resp.Status)
+		}
+		src = resp.Body
+	}
+
+	ext := ".apk"
+	if strings.HasSuffix(inputName(input), ".aab") {
+		ext = ".aab"
+	}
+	file, err := os.CreateTemp("", "boolseeker-*"+ext)
+	if err != nil {
+		return "", nil, fmt.Errorf("✖ Error staging %s: %w", input, err)
+	}
+	cleanup := func() { os.Remove(file.Name()) }
+
+	if _, err := io.Copy(file, src); err != nil {
+		file.Close()
+		cleanup()
+		return "", nil, fmt.Errorf("✖ Error staging %s: %w", input, err)
+	}
+	if err := file.Close(); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("✖ Error staging %s: %w", input, err)
+	}
+	return file.Name(), cleanup, nil
+}
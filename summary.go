@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ScanStats are the totals of one app's scan, printed after the findings.
+type ScanStats struct {
+	SmaliFiles     int
+	SoFiles        int
+	BooleanMethods int
+	MatchedMethods int
+	Decode         time.Duration
+	Scan           time.Duration
+}
+
+// PrintScanStats prints the totals of a scan on one line; with verbose, it
+// also prints the methods matched per category and how long decoding and
+// scanning took.
+func PrintScanStats(stats ScanStats, summaries []CategorySummary, verbose bool) {
+	fmt.Fprintln(status, green("✔ Scanned %d smali files and %d .so files: %d boolean methods, %d with keywords", stats.SmaliFiles, stats.SoFiles, stats.BooleanMethods, stats.MatchedMethods))
+	if !verbose {
+		fmt.Fprintln(status)
+		return
+	}
+
+	counts := make([]string, 0, len(summaries))
+	for _, summary := range summaries {
+		counts = append(counts, fmt.Sprintf("%s %d", summary.ID, summary.Methods))
+	}
+	fmt.Fprintf(status, "  Methods per category: %s\n", strings.Join(counts, ", "))
+	fmt.Fprintf(status, "  Decode %s, scan %s\n", stats.Decode.Round(time.Millisecond), stats.Scan.Round(time.Millisecond))
+	fmt.Fprintln(status)
+}
+
+func PrintSummaryTable(summaries []CategorySummary) {
+	headers := []string{"Category", "Methods", "Keywords", "Severity"}
+	rows := [][]string{}
+	widths := make([]int, len(headers))
+	for i, header := range headers {
+		widths[i] = len(header)
+	}
+	for _, summary := range summaries {
+		row := []string{summary.Category, strconv.Itoa(summary.Methods), strconv.Itoa(summary.Keywords), summary.Severity}
+		for i, cell := range row {
+			widths[i] = max(widths[i], len(cell))
+		}
+		rows = append(rows, row)
+	}
+
+	format := func(row []string) string {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = fmt.Sprintf("%-*s", widths[i], cell)
+		}
+		return strings.TrimRight(strings.Join(cells, " | "), " ")
+	}
+
+	fmt.Fprintln(status)
+	fmt.Fprintln(status, yellow("%s", format(headers)))
+	separators := make([]string, len(widths))
+	for i, width := range widths {
+		separators[i] = strings.Repeat("-", width)
+	}
+	fmt.Fprintln(status, strings.Join(separators, "-+-"))
+	for i, row := range rows {
+		if summaries[i].Methods > 0 {
+			fmt.Fprintln(status, cyan("%s", format(row)))
+		} else {
+			fmt.Fprintln(status, format(row))
+		}
+	}
+}
+
+// PrintSummaryLine prints the counts of a scan as one line of
+// space-separated key=value fields for scripts: the APK, the methods per
+// category, with -so the .so files with keywords, and the total number of
+// boolean methods. The APK is quoted when it contains spaces, quotes or =.
+func PrintSummaryLine(w io.Writer, apk string, summaries []CategorySummary, native *NativeResults, totalMethods int) {
+	if strings.ContainsAny(apk, " \t\"=") {
+		apk = strconv.Quote(apk)
+	}
+	fields := []string{"BOOLSEEKER_SUMMARY", "apk=" + apk}
+	for _, summary := range summaries {
+		fields = append(fields, fmt.Sprintf("%s=%d", summary.ID, summary.Methods))
+	}
+	if native != nil {
+		fields = append(fields, fmt.Sprintf("so=%d", len(native.Keywords)))
+	}
+	fields = append(fields, fmt.Sprintf("total=%d", totalMethods))
+	fmt.Fprintln(w, strings.Join(fields, " "))
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+func PrintVerdict(verdict *Verdict) {
+	fmt.Fprintln(console, yellow("✔ Verdict: %s anti-tamper coverage", verdict.Coverage))
+	parts := make([]string, 0, len(verdict.Categories))
+	for _, category := range verdict.Categories {
+		part := fmt.Sprintf("%s: %s", category.Category, category.Level)
+		switch {
+		case category.Methods == 1:
+			part += " (1 method)"
+		case category.Methods > 1:
+			part += fmt.Sprintf(" (%d methods)", category.Methods)
+		}
+		parts = append(parts, part)
+	}
+	fmt.Fprintf(console, "  %s\n", strings.Join(parts, ", "))
+	fmt.Fprintln(console)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// apktoolVersion runs the apktool at path with --version, returning "not
+// found" when there is none so --version works without apktool.
+func apktoolVersion(path string) string {
+	path, err := lookPathScript(path)
+	if err != nil {
+		return "not found"
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, path, "--version").Output()
+	if err != nil {
+		return fmt.Sprintf("unknown (%v)", err)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// PrintVersion prints the Boolseeker version, the Go toolchain and VCS
+// revision it was built from, and the version of the apktool at apktoolPath.
+func PrintVersion(w io.Writer, apktoolPath string) {
+	fmt.Fprintf(w, "Boolseeker version %s\n", version)
+	if info, ok := debug.ReadBuildInfo(); ok {
+		fmt.Fprintf(w, "go: %s\n", info.GoVersion)
+		settings := make(map[string]string)
+		for _, setting := range info.Settings {
+			settings[setting.Key] = setting.Value
+		}
+		if revision := settings["vcs.revision"]; revision != "" {
+			if settings["vcs.modified"] == "true" {
+				revision += " (modified)"
+			}
+			fmt.Fprintf(w, "commit: %s %s\n", revision, settings["vcs.time"])
+		} else if info.Main.Version != "" && info.Main.Version != "(devel)" {
+			// go install module@version builds carry no VCS settings.
+			fmt.Fprintf(w, "module: %s %s\n", info.Main.Path, info.Main.Version)
+		}
+	}
+	fmt.Fprintf(w, "apktool: %s\n", apktoolVersion(apktoolPath))
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// PostWebhook posts report as JSON to url, retrying server errors. Each
+// attempt is bounded by timeout, and the delivery stops when ctx is done.
+func PostWebhook(ctx context.Context, url, secret string, timeout time.Duration, retries int, report *Report) error {
+	body, err := marshalJSON(report, false)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{Proxy: http.ProxyFromEnvironment},
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("webhook delivery to %s failed: %w", url, ctx.Err())
+			case <-time.After(time.Duration(attempt) * time.Second):
+			}
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "boolseeker/"+version)
+		if secret != "" {
+			mac := hmac.New(sha256.New, []byte(secret))
+			mac.Write(body)
+			req.Header.Set("X-Boolseeker-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("webhook delivery to %s failed: %w", url, ctx.Err())
+			}
+			lastErr = err
+			continue
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return nil
+		}
+		lastErr = fmt.Errorf("unexpected status %s", resp.Status)
+		if resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			break
+		}
+	}
+	return fmt.Errorf("webhook delivery to %s failed: %w", url, lastErr)
+}